@@ -3,23 +3,29 @@ package main
 import (
 	"context"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"wugo/internal/app"
-	"wugo/internal/image"
 	"wugo/internal/wallpaper"
 )
 
 func main() {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	deps := app.Deps{
-		Processor: image.NewProcessor(nil, nil),
 		Setter:    wallpaper.NewKDESetter(),
 		Out:       os.Stdout,
 		Err:       os.Stderr,
 		MkdirAll:  os.MkdirAll,
 		HomeDir:   os.UserHomeDir,
+		ConfigDir: os.UserConfigDir,
+		Now:       time.Now,
 	}
 
-	os.Exit(app.Main(ctx, os.Args[1:], deps))
+	code := app.Main(ctx, os.Args[1:], deps)
+	stop()
+	os.Exit(code)
 }
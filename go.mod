@@ -3,3 +3,15 @@ module wugo
 go 1.24.2
 
 require github.com/godbus/dbus/v5 v5.1.0
+
+require (
+	github.com/pkg/sftp v1.13.10
+	golang.org/x/crypto v0.42.0
+	golang.org/x/image v0.31.0
+	golang.org/x/net v0.44.0
+)
+
+require (
+	github.com/kr/fs v0.1.0 // indirect
+	golang.org/x/sys v0.36.0 // indirect
+)
@@ -9,15 +9,35 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/godbus/dbus/v5"
+
+	"wugo/internal/config"
+	"wugo/internal/hooks"
+	"wugo/internal/library"
 	"wugo/internal/wallpaper"
 )
 
 var ErrUsage = errors.New("usage")
 
 type Options struct {
-	SaveDir string
-	NoMove  bool
+	SaveDir    string
+	NoMove     bool
+	Headers    []string
+	UserAgent  string
+	Referer    string
+	CookieFile string
+	Proxy      string
+	CAFile     string
+	Offline    bool
+	Refresh    bool
+	Purity     string
+	Ratio      string
+	AtLeast    string
+	Notify     bool
+	NoRollback bool
 }
 
 type ImageProcessor interface {
@@ -31,11 +51,23 @@ type Deps struct {
 	Err       io.Writer
 	MkdirAll  func(path string, perm fs.FileMode) error
 	HomeDir   func() (string, error)
+	ConfigDir func() (string, error)
+	CacheDir  func() (string, error)
+	Now       func() time.Time
+	After     func(d time.Duration) <-chan time.Time
+	// SessionBus opens a private connection to the D-Bus session bus.
+	SessionBus func() (*dbus.Conn, error)
 }
 
 func Main(ctx context.Context, args []string, deps Deps) int {
 	deps = withDefaults(deps)
 
+	if len(args) > 0 && !isFile(args[0]) {
+		if cmd, ok := command(args[0]); ok {
+			return cmd(ctx, args[1:], deps)
+		}
+	}
+
 	opts, input, err := ParseArgs(args)
 	if err != nil {
 		if errors.Is(err, ErrUsage) {
@@ -47,6 +79,21 @@ func Main(ctx context.Context, args []string, deps Deps) int {
 		return 2
 	}
 
+	cfg, err := loadConfig(deps.ConfigDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to load config:", err)
+		return 1
+	}
+
+	if deps.Processor == nil {
+		processor, err := newProcessor(opts, cfg, deps)
+		if err != nil {
+			fmt.Fprintln(deps.Err, "Failed to configure downloads:", err)
+			return 1
+		}
+		deps.Processor = processor
+	}
+
 	saveDir, err := resolveSaveDir(opts.SaveDir, deps.HomeDir)
 	if err != nil {
 		fmt.Fprintln(deps.Err, "Failed to resolve save directory:", err)
@@ -58,53 +105,287 @@ func Main(ctx context.Context, args []string, deps Deps) int {
 		return 1
 	}
 
+	ev := hooks.Event{Source: input, Backend: backendName(deps.Setter), Targets: wallpaperTargets}
+	if isDownload(input) {
+		ev.Hook = hooks.PreDownload
+		if err := hooks.Run(ctx, cfg.Hooks.PreDownload, ev, deps.Err); err != nil {
+			fmt.Fprintln(deps.Err, "Failed to run hook:", err)
+			return 1
+		}
+	}
+
 	localPath, err := deps.Processor.Process(ctx, input, saveDir, opts.NoMove)
 	if err != nil {
 		fmt.Fprintln(deps.Err, "Failed to process image:", err)
+		ev.Hook, ev.Error = hooks.OnFailure, err.Error()
+		_ = hooks.Run(ctx, cfg.Hooks.OnFailure, ev, deps.Err)
 		return 1
 	}
 
-	hadErr := false
-	if err := deps.Setter.SetDesktop(ctx, localPath); err != nil {
-		fmt.Fprintln(deps.Err, "Failed to set desktop wallpaper:", err)
-		hadErr = true
-	}
-	if err := deps.Setter.SetLockscreen(ctx, localPath); err != nil {
-		fmt.Fprintln(deps.Err, "Failed to set lockscreen wallpaper:", err)
-		hadErr = true
+	return setWallpaper(ctx, deps, cfg, saveDir, localPath, setOptions{notify: opts.Notify, noRollback: opts.NoRollback})
+}
+
+// setOptions changes how setWallpaper applies an image.
+type setOptions struct {
+	notify     bool
+	noRollback bool
+	// noActions leaves Undo and Favorite off notifications, so commands
+	// that keep running do not wait for an answer after every change.
+	noActions bool
+}
+
+// addSetFlags registers the setOptions flags on commands that set
+// wallpapers.
+func addSetFlags(fs *flag.FlagSet) *setOptions {
+	so := &setOptions{}
+	fs.BoolVar(&so.notify, "notify", false, "Show a desktop notification after setting")
+	fs.BoolVar(&so.noRollback, "no-rollback", false, "Keep the targets that were set when another fails")
+	return so
+}
+
+// wallpaperTargets are what setWallpaper changes, as reported to hooks.
+var wallpaperTargets = []string{"desktop", "lockscreen"}
+
+// setWallpaper applies localPath to the desktop and lock screen and records
+// it in the library, running the hooks of cfg. It returns the exit code.
+func setWallpaper(ctx context.Context, deps Deps, cfg config.Config, saveDir, localPath string, so setOptions) int {
+	ev := hooks.Event{
+		Image:   localPath,
+		Source:  sourceOf(saveDir, localPath),
+		Backend: backendName(deps.Setter),
+		Targets: wallpaperTargets,
 	}
 
-	if hadErr {
+	shown, errs := applyTargets(ctx, deps, localPath, so.noRollback)
+	if len(errs) > 0 {
+		ev.Hook, ev.Error = hooks.OnFailure, strings.Join(errs, "; ")
+		_ = hooks.Run(ctx, cfg.Hooks.OnFailure, ev, deps.Err)
 		return 1
 	}
 
+	var undo func(context.Context) error
+	if so.notify && !so.noActions {
+		undo = undoSet(deps, cfg, saveDir, localPath, shown)
+	}
+	if err := library.MarkSet(saveDir, localPath, deps.Now()); err != nil {
+		fmt.Fprintln(deps.Err, "Failed to update library:", err)
+	}
+
 	fmt.Fprintln(deps.Out, "Wallpaper set successfully:", localPath)
+	if cfg.Palette.Auto {
+		autoPalette(deps, cfg.Palette, localPath)
+	}
+
+	ev.Hook = hooks.PostSet
+	hookErr := hooks.Run(ctx, cfg.Hooks.PostSet, ev, deps.Err)
+	if so.notify {
+		notifySet(ctx, deps, saveDir, localPath, undo, !so.noActions)
+	}
+	if hookErr != nil {
+		fmt.Fprintln(deps.Err, "Failed to run hook:", hookErr)
+		return 1
+	}
 	return 0
 }
 
+// backendName names the wallpaper backend for hooks.
+func backendName(s wallpaper.Setter) string {
+	if named, ok := s.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return ""
+}
+
+// sourceOf returns where the library says path came from.
+func sourceOf(saveDir, path string) string {
+	ix, err := library.Open(saveDir)
+	if err != nil {
+		return ""
+	}
+	if e, ok := ix.Get(path); ok {
+		return e.SourceURL
+	}
+	return ""
+}
+
+// isDownload reports whether input names a remote image rather than a
+// local file, stdin or inline data.
+func isDownload(input string) bool {
+	if input == "-" || strings.HasPrefix(input, "data:") || strings.HasPrefix(input, "file:") {
+		return false
+	}
+	if _, err := os.Stat(input); err == nil {
+		return false
+	}
+	scheme, _, ok := strings.Cut(input, ":")
+	return ok && len(scheme) > 1 && !strings.ContainsAny(scheme, `/\`)
+}
+
+type commandFunc func(ctx context.Context, args []string, deps Deps) int
+
+// isFile reports whether name is an existing file. Such an argument is
+// set as wallpaper even when it is also a subcommand name, as it was
+// before subcommands existed.
+func isFile(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && !info.IsDir()
+}
+
+// command returns the subcommand called name. Anything else is treated as
+// an image input.
+func command(name string) (commandFunc, bool) {
+	switch name {
+	case "list":
+		return runList, true
+	case "info":
+		return runInfo, true
+	case "library":
+		return runLibrary, true
+	case "tag":
+		return runTag, true
+	case "fav":
+		return runFav(true), true
+	case "unfav":
+		return runFav(false), true
+	case "dedupe":
+		return runDedupe, true
+	case "prune":
+		return runPrune, true
+	case "import":
+		return runImport, true
+	case "random":
+		return runRandom, true
+	case "daemon":
+		return runDaemon, true
+	case "schedule":
+		return runSchedule, true
+	case "dynamic":
+		return runDynamic, true
+	case "appearance":
+		return runAppearance, true
+	case "palette":
+		return runPalette, true
+	}
+	return nil, false
+}
+
 func ParseArgs(args []string) (Options, string, error) {
 	fs := flag.NewFlagSet("wugo", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 
 	dir := fs.String("d", "", "Directory to save/move image")
 	noMove := fs.Bool("nm", false, "Do not move local file, use it from current location")
+	var headers stringList
+	fs.Var(&headers, "header", "Extra request header as Key:Value (repeatable)")
+	userAgent := fs.String("user-agent", "", "User-Agent header for downloads")
+	referer := fs.String("referer", "", "Referer header for downloads")
+	cookieFile := fs.String("cookie-file", "", "Netscape cookies.txt file for downloads")
+	proxyURL := fs.String("proxy", "", "Proxy URL (http, https, socks5, socks5h)")
+	caFile := fs.String("ca-file", "", "Extra PEM CA bundle to trust")
+	offline := fs.Bool("offline", false, "Do not touch the network, use cached downloads only")
+	refresh := fs.Bool("refresh", false, "Ignore cached downloads and fetch again")
+	purity := fs.String("purity", "", "Wallhaven purity: sfw, sketchy, nsfw (comma-separated)")
+	ratio := fs.String("ratio", "", "Wallhaven aspect ratio, e.g. 16x9")
+	atLeast := fs.String("atleast", "", "Wallhaven minimum resolution, e.g. 2560x1440")
+	so := addSetFlags(fs)
 
-	if err := fs.Parse(args); err != nil {
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
 		return Options{}, "", fmt.Errorf("parse flags: %w", err)
 	}
 
-	if fs.NArg() < 1 {
+	if len(positional) < 1 {
 		return Options{}, "", ErrUsage
 	}
 
-	return Options{SaveDir: *dir, NoMove: *noMove}, fs.Arg(0), nil
+	opts := Options{
+		SaveDir:    *dir,
+		NoMove:     *noMove,
+		Headers:    headers,
+		UserAgent:  *userAgent,
+		Referer:    *referer,
+		CookieFile: *cookieFile,
+		Proxy:      *proxyURL,
+		CAFile:     *caFile,
+		Offline:    *offline,
+		Refresh:    *refresh,
+		Purity:     *purity,
+		Ratio:      *ratio,
+		AtLeast:    *atLeast,
+		Notify:     so.notify,
+		NoRollback: so.noRollback,
+	}
+	return opts, positional[0], nil
+}
+
+// parseInterspersed parses flags that may appear before or after positional
+// arguments, as in "wugo wallhaven:nature --purity sfw". Arguments after
+// "--" are always positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
 }
 
 func usage(w io.Writer) {
-	fmt.Fprintln(w, "Usage: wugo [-d dir] [-nm] <image-url-or-path>")
+	fmt.Fprintln(w, "Usage: wugo [options] <image-url-or-path | - | data:...>")
+	fmt.Fprintln(w, "       wugo list [-d dir] [--sort key] [--reverse] [--limit n] [filters]")
+	fmt.Fprintln(w, "       wugo info [-d dir] <file|current>")
+	fmt.Fprintln(w, "       wugo library rescan [-d dir]")
+	fmt.Fprintln(w, "       wugo tag add|rm [-d dir] <file|current> <tag>...")
+	fmt.Fprintln(w, "       wugo tag ls [-d dir] [file|current]")
+	fmt.Fprintln(w, "       wugo fav|unfav [-d dir] [file|current]")
+	fmt.Fprintln(w, "       wugo dedupe [-d dir] [--threshold n] [--dry-run]")
+	fmt.Fprintln(w, "       wugo import [-d dir] [--copy|--move|--link] [--recursive] [--folder-tags] [--jobs n] <dir>")
+	fmt.Fprintln(w, "       wugo prune [-d dir] [--keep-last n] [--max-size 5G] [--older-than 90d] [--dry-run]")
+	fmt.Fprintln(w, "       wugo random [-d dir] [--notify] [filters]")
+	fmt.Fprintln(w, "       wugo daemon [-d dir] [--interval 30m] [--prune-every 24h] [--dynamic bundle] [--notify] [filters]")
+	fmt.Fprintln(w, "       wugo schedule preview")
+	fmt.Fprintln(w, "       wugo dynamic set|preview [-d dir] [--notify] <bundle>")
+	fmt.Fprintln(w, "       wugo dynamic export [-d dir] [-o background.xml] <bundle>")
+	fmt.Fprintln(w, "       wugo appearance follow [-d dir] [--notify]")
+	fmt.Fprintln(w, "       wugo appearance set [-d dir] [--notify] <light|dark>")
+	fmt.Fprintln(w, "       wugo palette [-d dir] [--export] [-o dir] [--format f] [file|current]")
+	fmt.Fprintln(w, "Filters: --format f, --source s, --min WxH, --tag t (repeatable), --fav")
 	fmt.Fprintln(w, "Options:")
-	fmt.Fprintln(w, "  -d   Directory to save/move image (default: ~/wallpapers)")
-	fmt.Fprintln(w, "  -nm  Do not move local file, use it from current location")
+	fmt.Fprintln(w, "  -d             Directory to save/move image (default: ~/wallpapers)")
+	fmt.Fprintln(w, "  -nm            Do not move local file, use it from current location")
+	fmt.Fprintln(w, "  --header K:V   Extra request header for downloads (repeatable)")
+	fmt.Fprintln(w, "  --user-agent   User-Agent header for downloads")
+	fmt.Fprintln(w, "  --referer      Referer header for downloads")
+	fmt.Fprintln(w, "  --cookie-file  Netscape cookies.txt file for downloads")
+	fmt.Fprintln(w, "  --proxy        Proxy URL (http, https, socks5, socks5h)")
+	fmt.Fprintln(w, "  --ca-file      Extra PEM CA bundle to trust")
+	fmt.Fprintln(w, "  --offline      Do not touch the network, use cached downloads only")
+	fmt.Fprintln(w, "  --refresh      Ignore cached downloads and fetch again")
+	fmt.Fprintln(w, "  --purity       Wallhaven purity: sfw, sketchy, nsfw (comma-separated)")
+	fmt.Fprintln(w, "  --ratio        Wallhaven aspect ratio, e.g. 16x9")
+	fmt.Fprintln(w, "  --atleast      Wallhaven minimum resolution, e.g. 2560x1440")
+	fmt.Fprintln(w, "  --notify       Show a desktop notification with Undo and Favorite actions")
+	fmt.Fprintln(w, "  --no-rollback  Keep the targets that were set when another target fails")
 }
 
 func resolveSaveDir(dir string, homeDir func() (string, error)) (string, error) {
@@ -119,6 +400,14 @@ func resolveSaveDir(dir string, homeDir func() (string, error)) (string, error)
 	return filepath.Abs(dir)
 }
 
+func loadConfig(configDir func() (string, error)) (config.Config, error) {
+	path, err := config.Path(configDir)
+	if err != nil {
+		return config.Config{}, err
+	}
+	return config.Load(path)
+}
+
 func withDefaults(deps Deps) Deps {
 	if deps.Out == nil {
 		deps.Out = io.Discard
@@ -132,6 +421,21 @@ func withDefaults(deps Deps) Deps {
 	if deps.HomeDir == nil {
 		deps.HomeDir = os.UserHomeDir
 	}
+	if deps.ConfigDir == nil {
+		deps.ConfigDir = os.UserConfigDir
+	}
+	if deps.CacheDir == nil {
+		deps.CacheDir = os.UserCacheDir
+	}
+	if deps.Now == nil {
+		deps.Now = time.Now
+	}
+	if deps.After == nil {
+		deps.After = time.After
+	}
+	if deps.SessionBus == nil {
+		deps.SessionBus = func() (*dbus.Conn, error) { return dbus.ConnectSessionBus() }
+	}
 
 	return deps
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -51,6 +52,7 @@ func TestMainUsage(t *testing.T) {
 		Err:       &out,
 		MkdirAll:  func(string, fs.FileMode) error { return nil },
 		HomeDir:   func() (string, error) { return "/tmp", nil },
+		ConfigDir: func() (string, error) { return t.TempDir(), nil },
 	}
 
 	code := Main(context.Background(), nil, deps)
@@ -76,7 +78,8 @@ func TestMainSuccess(t *testing.T) {
 			mkdirPath = path
 			return nil
 		},
-		HomeDir: func() (string, error) { return "/home/test", nil },
+		HomeDir:   func() (string, error) { return "/home/test", nil },
+		ConfigDir: func() (string, error) { return t.TempDir(), nil },
 	}
 
 	code := Main(context.Background(), []string{"/tmp/input.png"}, deps)
@@ -95,6 +98,28 @@ func TestMainSuccess(t *testing.T) {
 	}
 }
 
+func TestMainFileNamedLikeSubcommand(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("random", []byte("img"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	var out bytes.Buffer
+	processor := &fakeProcessor{result: "/tmp/image.png"}
+	code := Main(context.Background(), []string{"random", "-nm"}, Deps{
+		Processor: processor,
+		Setter:    &fakeSetter{},
+		Out:       &out,
+		Err:       &out,
+		MkdirAll:  func(string, fs.FileMode) error { return nil },
+		HomeDir:   func() (string, error) { return "/home/test", nil },
+		ConfigDir: func() (string, error) { return t.TempDir(), nil },
+	})
+	if code != 0 || processor.input != "random" {
+		t.Fatalf("expected the file to be set, got %d and input %q: %s", code, processor.input, out.String())
+	}
+}
+
 func TestMainProcessorError(t *testing.T) {
 	var out bytes.Buffer
 	processor := &fakeProcessor{err: errors.New("boom")}
@@ -105,6 +130,7 @@ func TestMainProcessorError(t *testing.T) {
 		Err:       &out,
 		MkdirAll:  func(string, fs.FileMode) error { return nil },
 		HomeDir:   func() (string, error) { return "/home/test", nil },
+		ConfigDir: func() (string, error) { return t.TempDir(), nil },
 	}
 
 	code := Main(context.Background(), []string{"/tmp/input.png"}, deps)
@@ -127,6 +153,7 @@ func TestMainSetterError(t *testing.T) {
 		Err:       &out,
 		MkdirAll:  func(string, fs.FileMode) error { return nil },
 		HomeDir:   func() (string, error) { return "/home/test", nil },
+		ConfigDir: func() (string, error) { return t.TempDir(), nil },
 	}
 
 	code := Main(context.Background(), []string{"/tmp/input.png"}, deps)
@@ -140,3 +167,21 @@ func TestMainSetterError(t *testing.T) {
 		t.Fatalf("expected desktop error output, got %s", out.String())
 	}
 }
+
+func TestParseArgsInterspersedFlags(t *testing.T) {
+	opts, input, err := ParseArgs([]string{"wallhaven:nature 4k", "--purity", "sfw", "--ratio", "16x9"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if input != "wallhaven:nature 4k" || opts.Purity != "sfw" || opts.Ratio != "16x9" {
+		t.Fatalf("unexpected parse result: %q %+v", input, opts)
+	}
+
+	_, input, err = ParseArgs([]string{"-nm", "--", "-weird.png"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if input != "-weird.png" {
+		t.Fatalf("expected input after --, got %q", input)
+	}
+}
@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"wugo/internal/appearance"
+	"wugo/internal/config"
+	"wugo/internal/library"
+)
+
+func runAppearance(ctx context.Context, args []string, deps Deps) int {
+	if len(args) == 0 {
+		return commandUsage(deps, "appearance", fmt.Errorf("expected subcommand: follow or set"))
+	}
+	sub := args[0]
+
+	fs := flag.NewFlagSet("wugo appearance", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("d", "", "Save directory")
+	so := addSetFlags(fs)
+	positional, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		return commandUsage(deps, "appearance", err)
+	}
+
+	var dark bool
+	switch sub {
+	case "follow":
+		if len(positional) != 0 {
+			return commandUsage(deps, "appearance", fmt.Errorf("unexpected argument: %s", positional[0]))
+		}
+	case "set":
+		if len(positional) != 1 || (positional[0] != library.LightTag && positional[0] != library.DarkTag) {
+			return commandUsage(deps, "appearance", fmt.Errorf("expected light or dark"))
+		}
+		dark = positional[0] == library.DarkTag
+	default:
+		return commandUsage(deps, "appearance", fmt.Errorf("unknown subcommand: %s", sub))
+	}
+
+	cfg, err := loadConfig(deps.ConfigDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to load config:", err)
+		return 1
+	}
+	saveDir, err := resolveSaveDir(*dir, deps.HomeDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to resolve save directory:", err)
+		return 1
+	}
+
+	if sub == "set" {
+		return setVariant(ctx, deps, cfg, saveDir, dark, *so)
+	}
+	return followAppearance(ctx, deps, cfg, saveDir, *so)
+}
+
+// followAppearance applies the desktop color scheme at startup and again
+// whenever the portal reports a change, until ctx is cancelled.
+func followAppearance(ctx context.Context, deps Deps, cfg config.Config, saveDir string, so setOptions) int {
+	so.noActions = true
+	conn, err := deps.SessionBus()
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to connect to session bus:", err)
+		return 1
+	}
+	defer conn.Close()
+
+	var applied bool
+	var shown appearance.Scheme
+	apply := func(s appearance.Scheme) {
+		if applied && s == shown {
+			return
+		}
+		fmt.Fprintln(deps.Out, "Color scheme:", s)
+		// No preference means the default, which is light.
+		if setVariant(ctx, deps, cfg, saveDir, s == appearance.Dark, so) == 0 {
+			applied, shown = true, s
+		}
+	}
+
+	if s, err := appearance.Read(conn); err != nil {
+		fmt.Fprintln(deps.Err, "Failed to read color scheme:", err)
+	} else {
+		apply(s)
+	}
+	if err := appearance.Watch(ctx, conn, apply); err != nil {
+		fmt.Fprintln(deps.Err, "Failed to watch color scheme:", err)
+		return 1
+	}
+	return 0
+}
+
+// setVariant sets the light or dark variant of the current wallpaper's
+// pair. Without a pair it sets a random image tagged light or dark.
+func setVariant(ctx context.Context, deps Deps, cfg config.Config, saveDir string, dark bool, so setOptions) int {
+	ix, err := library.Open(saveDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to open library:", err)
+		return 1
+	}
+	pairs := variantPairs(ix, cfg.Appearance, deps.Err)
+
+	current, ok := ix.Current()
+	if ok {
+		if file, ok := library.Variant(pairs, current.File, dark); ok {
+			if file == current.File {
+				fmt.Fprintln(deps.Out, "Already showing:", ix.Path(*current))
+				return 0
+			}
+			return setWallpaper(ctx, deps, cfg, saveDir, ix.Path(library.Entry{File: file}), so)
+		}
+	}
+
+	tag := library.LightTag
+	if dark {
+		tag = library.DarkTag
+	}
+	if ok {
+		if labels := ix.LabelsOf(*current); labels.HasTag(tag) {
+			fmt.Fprintln(deps.Out, "Already showing:", ix.Path(*current))
+			return 0
+		}
+	}
+	e, err := ix.Random(library.Query{Tags: []string{tag}}, nil)
+	if errors.Is(err, library.ErrNoMatch) {
+		fmt.Fprintf(deps.Err, "Failed to pick image: no pair and no image tagged %s\n", tag)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to pick image:", err)
+		return 1
+	}
+	return setWallpaper(ctx, deps, cfg, saveDir, ix.Path(*e), so)
+}
+
+// variantPairs returns the configured pairs followed by those formed by
+// tags. Configured pairs naming images that are not in the library are
+// skipped with a warning.
+func variantPairs(ix *library.Index, cfg config.Appearance, warn io.Writer) []library.Pair {
+	var pairs []library.Pair
+	for _, p := range cfg.Pairs {
+		light, err := ix.Resolve(p.Light)
+		if err == nil {
+			var dark *library.Entry
+			if dark, err = ix.Resolve(p.Dark); err == nil {
+				pairs = append(pairs, library.Pair{Light: light.File, Dark: dark.File})
+				continue
+			}
+		}
+		fmt.Fprintf(warn, "Warning: skipping appearance pair %s/%s: %v\n", p.Light, p.Dark, err)
+	}
+	return append(pairs, ix.TagPairs()...)
+}
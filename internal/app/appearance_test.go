@@ -0,0 +1,191 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"wugo/internal/dbustest"
+)
+
+// channelSetter reports each desktop wallpaper on a channel, for commands
+// that set wallpapers from another goroutine.
+type channelSetter struct {
+	fakeSetter
+	paths chan string
+}
+
+func (c *channelSetter) SetDesktop(_ context.Context, path string) error {
+	c.paths <- path
+	return nil
+}
+
+type fakePortal struct{ scheme uint32 }
+
+func (p *fakePortal) ReadOne(_, _ string) (dbus.Variant, *dbus.Error) {
+	return dbus.MakeVariant(p.scheme), nil
+}
+
+func tagForAppearance(t *testing.T, dir string) {
+	t.Helper()
+	writeTestPNG(t, filepath.Join(dir, "forest-day.png"), 8, 8)
+	writeTestPNG(t, filepath.Join(dir, "forest-night.png"), 9, 9)
+	writeTestPNG(t, filepath.Join(dir, "moon.png"), 10, 10)
+	for _, args := range [][]string{
+		{"library", "rescan", "-d", dir},
+		{"tag", "add", "forest-day.png", "pair:forest", "light", "-d", dir},
+		{"tag", "add", "forest-night.png", "pair:forest", "dark", "-d", dir},
+		{"tag", "add", "moon.png", "dark", "-d", dir},
+	} {
+		if code := Main(context.Background(), args, Deps{}); code != 0 {
+			t.Fatalf("%v failed with %d", args, code)
+		}
+	}
+}
+
+func TestAppearanceSetSwapsPair(t *testing.T) {
+	dir := t.TempDir()
+	tagForAppearance(t, dir)
+	setter := &recordingSetter{}
+	run := func(args ...string) (int, string) {
+		var out bytes.Buffer
+		code := Main(context.Background(), args, Deps{
+			Setter:    setter,
+			Out:       &out,
+			Err:       &out,
+			ConfigDir: func() (string, error) { return t.TempDir(), nil },
+		})
+		return code, out.String()
+	}
+
+	if code, out := run("appearance", "set", "dark", "-d", dir); code != 0 {
+		t.Fatalf("set dark failed: %s", out)
+	}
+	first := filepath.Base(setter.paths[0])
+	if first != "forest-night.png" && first != "moon.png" {
+		t.Fatalf("expected a dark image, got %s", first)
+	}
+	setter.paths = nil
+
+	if code, out := run(filepath.Join(dir, "forest-day.png"), "-nm", "-d", dir); code != 0 {
+		t.Fatalf("set failed: %s", out)
+	}
+	if code, out := run("appearance", "set", "dark", "-d", dir); code != 0 {
+		t.Fatalf("set dark failed: %s", out)
+	}
+	if code, out := run("appearance", "set", "dark", "-d", dir); code != 0 || !strings.Contains(out, "Already showing") {
+		t.Fatalf("expected dark variant to stay, got %d: %s", code, out)
+	}
+	if code, out := run("appearance", "set", "light", "-d", dir); code != 0 {
+		t.Fatalf("set light failed: %s", out)
+	}
+
+	var names []string
+	for _, p := range setter.paths {
+		names = append(names, filepath.Base(p))
+	}
+	if strings.Join(names, ",") != "forest-day.png,forest-night.png,forest-day.png" {
+		t.Fatalf("unexpected wallpapers: %v", names)
+	}
+}
+
+func TestAppearanceSkipsMissingConfiguredPair(t *testing.T) {
+	dir := t.TempDir()
+	tagForAppearance(t, dir)
+	configDir := writeConfig(t, `{"appearance": {"pairs": [{"light": "gone.png", "dark": "moon.png"}]}}`)
+	setter := &recordingSetter{}
+	run := func(args ...string) (int, string) {
+		var out bytes.Buffer
+		code := Main(context.Background(), args, Deps{
+			Setter:    setter,
+			Out:       &out,
+			Err:       &out,
+			ConfigDir: func() (string, error) { return configDir, nil },
+		})
+		return code, out.String()
+	}
+
+	if code, out := run(filepath.Join(dir, "forest-day.png"), "-nm", "-d", dir); code != 0 {
+		t.Fatalf("set failed: %s", out)
+	}
+	code, out := run("appearance", "set", "dark", "-d", dir)
+	if code != 0 || !strings.Contains(out, "Warning: skipping appearance pair gone.png/moon.png") {
+		t.Fatalf("expected a warning, got %d: %s", code, out)
+	}
+	if last := filepath.Base(setter.paths[len(setter.paths)-1]); last != "forest-night.png" {
+		t.Fatalf("expected the tag pair to be used, got %s", last)
+	}
+}
+
+func TestAppearanceFollowsPortal(t *testing.T) {
+	dir := t.TempDir()
+	tagForAppearance(t, dir)
+
+	address := dbustest.Start(t)
+	portal := dbustest.Connect(t, address)
+	if err := portal.Export(&fakePortal{scheme: 2}, "/org/freedesktop/portal/desktop", "org.freedesktop.portal.Settings"); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if _, err := portal.RequestName("org.freedesktop.portal.Desktop", dbus.NameFlagDoNotQueue); err != nil {
+		t.Fatalf("request name: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	setter := &channelSetter{paths: make(chan string, 8)}
+	done := make(chan int, 1)
+	go func() {
+		done <- Main(ctx, []string{"appearance", "follow", "-d", dir}, Deps{
+			Setter:     setter,
+			ConfigDir:  func() (string, error) { return t.TempDir(), nil },
+			SessionBus: func() (*dbus.Conn, error) { return dbus.Connect(address) },
+		})
+	}()
+
+	next := func() string {
+		t.Helper()
+		select {
+		case p := <-setter.paths:
+			return filepath.Base(p)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no wallpaper set")
+			return ""
+		}
+	}
+	if got := next(); got != "forest-day.png" {
+		t.Fatalf("expected the light image at startup, got %s", got)
+	}
+
+	// Emit until the watcher, whose match rule is added asynchronously,
+	// picks the change up.
+	var got string
+	for deadline := time.Now().Add(5 * time.Second); got == "" && time.Now().Before(deadline); {
+		if err := portal.Emit("/org/freedesktop/portal/desktop", "org.freedesktop.portal.Settings.SettingChanged",
+			"org.freedesktop.appearance", "color-scheme", dbus.MakeVariant(uint32(1))); err != nil {
+			t.Fatalf("emit: %v", err)
+		}
+		select {
+		case p := <-setter.paths:
+			got = filepath.Base(p)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	if got != "forest-night.png" {
+		t.Fatalf("expected the dark variant after the change, got %q", got)
+	}
+
+	cancel()
+	if code := <-done; code != 0 {
+		t.Fatalf("follow exited with %d", code)
+	}
+	select {
+	case p := <-setter.paths:
+		t.Fatalf("expected repeated signals to be ignored, got %s", p)
+	default:
+	}
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"wugo/internal/library"
+)
+
+// runDedupe keeps the best copy of every group of near-duplicate images and
+// removes the rest, carrying their tags over to the kept copy.
+func runDedupe(_ context.Context, args []string, deps Deps) int {
+	fs := flag.NewFlagSet("wugo dedupe", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("d", "", "Save directory")
+	threshold := fs.Int("threshold", library.DefaultThreshold, "Largest perceptual hash distance in bits (0-64)")
+	dryRun := fs.Bool("dry-run", false, "Only report what would be removed")
+	if err := fs.Parse(args); err != nil {
+		return commandUsage(deps, "dedupe", err)
+	}
+	if *threshold < 0 || *threshold > 64 {
+		return commandUsage(deps, "dedupe", fmt.Errorf("threshold must be between 0 and 64"))
+	}
+
+	ix, code := lockLibrary(deps, *dir)
+	if ix == nil {
+		return code
+	}
+	defer ix.Unlock()
+
+	current, _ := ix.Current()
+	removed, failed := 0, 0
+	for _, group := range ix.Duplicates(*threshold) {
+		keep := group[0]
+		fmt.Fprintf(deps.Out, "keep    %s (%s)\n", keep.File, resolution(keep))
+		for _, e := range group[1:] {
+			if current != nil && e.File == current.File {
+				fmt.Fprintf(deps.Out, "skip    %s (current wallpaper)\n", e.File)
+				continue
+			}
+			if *dryRun {
+				fmt.Fprintf(deps.Out, "remove  %s (%s)\n", e.File, resolution(e))
+				removed++
+				continue
+			}
+			ix.MergeLabels(keep, e)
+			if err := ix.Delete(e); err != nil {
+				fmt.Fprintln(deps.Err, "Failed to remove", e.File+":", err)
+				failed++
+				continue
+			}
+			fmt.Fprintf(deps.Out, "removed %s (%s)\n", e.File, resolution(e))
+			removed++
+		}
+	}
+
+	if *dryRun {
+		fmt.Fprintf(deps.Out, "Would remove %d near-duplicates\n", removed)
+		return 0
+	}
+	if err := ix.Save(); err != nil {
+		fmt.Fprintln(deps.Err, "Failed to save library:", err)
+		return 1
+	}
+	fmt.Fprintf(deps.Out, "Removed %d near-duplicates\n", removed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
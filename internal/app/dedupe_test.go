@@ -0,0 +1,75 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePattern(t *testing.T, path string, size int) {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for y := range size {
+		for x := range size {
+			if (x*4/size+y*3/size)%2 == 0 {
+				img.Set(x, y, color.Gray{Y: uint8(255 * x / size)})
+			}
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	dir := t.TempDir()
+	writePattern(t, filepath.Join(dir, "large.png"), 256)
+	writePattern(t, filepath.Join(dir, "small.png"), 64)
+	writeTestPNG(t, filepath.Join(dir, "plain.png"), 64, 64)
+
+	run := func(args ...string) (int, string) {
+		var out bytes.Buffer
+		code := Main(context.Background(), args, Deps{Out: &out, Err: &out})
+		return code, out.String()
+	}
+	if code, out := run("library", "rescan", "-d", dir); code != 0 {
+		t.Fatalf("rescan failed: %s", out)
+	}
+
+	code, out := run("dedupe", "-d", dir, "--dry-run")
+	if code != 0 || !strings.Contains(out, "keep    large.png") || !strings.Contains(out, "remove  small.png") ||
+		!strings.Contains(out, "Would remove 1") {
+		t.Fatalf("unexpected dry run %d: %s", code, out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "small.png")); err != nil {
+		t.Fatalf("dry run removed file: %v", err)
+	}
+
+	code, out = run("dedupe", "-d", dir)
+	if code != 0 || !strings.Contains(out, "Removed 1") {
+		t.Fatalf("unexpected dedupe %d: %s", code, out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "small.png")); !os.IsNotExist(err) {
+		t.Fatalf("expected small.png removed, got %v", err)
+	}
+	for _, name := range []string{"large.png", "plain.png"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("expected %s kept: %v", name, err)
+		}
+	}
+
+	if code, _ := run("dedupe", "-d", dir, "--threshold", "65"); code != 2 {
+		t.Fatalf("expected exit code 2 for bad threshold, got %d", code)
+	}
+}
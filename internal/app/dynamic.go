@@ -0,0 +1,248 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"wugo/internal/config"
+	"wugo/internal/dynamic"
+	"wugo/internal/image"
+	"wugo/internal/imaging"
+)
+
+// blendSteps is how many images a crossfade is split into, counting the
+// frame it starts from.
+const blendSteps = 10
+
+func runDynamic(ctx context.Context, args []string, deps Deps) int {
+	if len(args) == 0 {
+		return commandUsage(deps, "dynamic", fmt.Errorf("expected subcommand: set, preview or export"))
+	}
+	sub := args[0]
+
+	fs := flag.NewFlagSet("wugo dynamic", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("d", "", "Save directory")
+	output := fs.String("o", "", "Write the GNOME XML to this file")
+	so := addSetFlags(fs)
+	positional, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		return commandUsage(deps, "dynamic", err)
+	}
+	switch sub {
+	case "set", "preview", "export":
+	default:
+		return commandUsage(deps, "dynamic", fmt.Errorf("unknown subcommand: %s", sub))
+	}
+	if len(positional) != 1 {
+		return commandUsage(deps, "dynamic", fmt.Errorf("expected one bundle"))
+	}
+
+	cfg, err := loadConfig(deps.ConfigDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to load config:", err)
+		return 1
+	}
+	saveDir, err := resolveSaveDir(*dir, deps.HomeDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to resolve save directory:", err)
+		return 1
+	}
+	b, code := loadBundle(deps, cfg, saveDir, positional[0])
+	if b == nil {
+		return code
+	}
+
+	switch sub {
+	case "set":
+		path, _, err := dynamicFrame(saveDir, b, deps.Now())
+		if err != nil {
+			fmt.Fprintln(deps.Err, "Failed to pick frame:", err)
+			return 1
+		}
+		return setWallpaper(ctx, deps, cfg, saveDir, path, *so)
+	case "preview":
+		return previewDynamic(deps, b)
+	}
+	return exportDynamic(deps, b, *output)
+}
+
+// loadBundle loads a dynamic wallpaper, extracting archives into the state
+// directory of saveDir. It prints failures and returns nil with the exit
+// code.
+func loadBundle(deps Deps, cfg config.Config, saveDir, path string) (*dynamic.Bundle, int) {
+	b, err := dynamic.Load(expandHome(path, deps.HomeDir), filepath.Join(image.StateDir(saveDir), "dynamic"), cfg.Location)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to load dynamic wallpaper:", err)
+		return nil, 1
+	}
+	return b, 0
+}
+
+func previewDynamic(deps Deps, b *dynamic.Bundle) int {
+	now := deps.Now()
+	end := now.Add(previewWindow)
+
+	tw := tabwriter.NewWriter(deps.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FROM\tTO\tSHOWS")
+	for t := now; t.Before(end); {
+		c, err := b.Cycle(t)
+		if err != nil {
+			_ = tw.Flush()
+			fmt.Fprintln(deps.Err, "Failed to lay out frames:", err)
+			return 1
+		}
+		st := c.At(t)
+		if !st.Until.After(t) {
+			break
+		}
+		until := st.Until
+		if until.After(end) {
+			until = end
+		}
+		shows := filepath.Base(st.From)
+		if st.To != "" {
+			shows = fmt.Sprintf("%s -> %s", shows, filepath.Base(st.To))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", t.Format("Mon 15:04"), until.Format("Mon 15:04"), shows)
+		t = until
+	}
+	_ = tw.Flush()
+	return 0
+}
+
+func exportDynamic(deps Deps, b *dynamic.Bundle, output string) int {
+	c, err := b.Cycle(deps.Now())
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to lay out frames:", err)
+		return 1
+	}
+	if output == "" {
+		if err := dynamic.WriteGNOME(deps.Out, c); err != nil {
+			fmt.Fprintln(deps.Err, "Failed to export:", err)
+			return 1
+		}
+		return 0
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to export:", err)
+		return 1
+	}
+	if err := dynamic.WriteGNOME(f, c); err != nil {
+		_ = f.Close()
+		fmt.Fprintln(deps.Err, "Failed to export:", err)
+		return 1
+	}
+	if err := f.Close(); err != nil {
+		fmt.Fprintln(deps.Err, "Failed to export:", err)
+		return 1
+	}
+	fmt.Fprintln(deps.Out, "Exported GNOME background:", output)
+	return 0
+}
+
+// dynamicFrame returns the image b shows at now and when that changes.
+// During a crossfade it is a pre-blended image, one of blendSteps per
+// transition, kept in the state directory of saveDir until the next
+// transition is blended.
+func dynamicFrame(saveDir string, b *dynamic.Bundle, now time.Time) (string, time.Time, error) {
+	c, err := b.Cycle(now)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	st := c.At(now)
+	if st.To == "" {
+		return st.From, st.Until, nil
+	}
+
+	fade := st.Until.Sub(st.Since)
+	step := int(now.Sub(st.Since) * blendSteps / fade)
+	until := st.Since.Add(fade * time.Duration(step+1) / blendSteps)
+	if step == 0 {
+		return st.From, until, nil
+	}
+
+	sum := sha256.Sum256([]byte(st.From + "\x00" + st.To))
+	prefix := hex.EncodeToString(sum[:6]) + "-"
+	blendDir := filepath.Join(image.StateDir(saveDir), "dynamic", "blend")
+	path := filepath.Join(blendDir, fmt.Sprintf("%s%02d.jpg", prefix, step*100/blendSteps))
+	if _, err := os.Stat(path); err == nil {
+		return path, until, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", time.Time{}, err
+	}
+	removeBlends(blendDir, prefix)
+
+	from, _, err := imaging.Decode(st.From)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	to, _, err := imaging.Decode(st.To)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if err := imaging.SaveJPEG(path, imaging.Blend(from, to, float64(step)/blendSteps)); err != nil {
+		return "", time.Time{}, err
+	}
+	return path, until, nil
+}
+
+// removeBlends deletes the blends of transitions other than the one whose
+// files start with keep.
+func removeBlends(dir, keep string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), keep) {
+			_ = os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
+
+// dynamicPlayer shows a dynamic wallpaper in the daemon, setting a new
+// image only when the frame changes.
+type dynamicPlayer struct {
+	bundle *dynamic.Bundle
+	shown  string
+}
+
+func newDynamicPlayer(deps Deps, cfg config.Config, saveDir, path string) (*dynamicPlayer, int) {
+	b, code := loadBundle(deps, cfg, saveDir, path)
+	if b == nil {
+		return nil, code
+	}
+	return &dynamicPlayer{bundle: b}, 0
+}
+
+// step sets the current frame if it changed and returns how long to sleep.
+func (p *dynamicPlayer) step(ctx context.Context, deps Deps, cfg config.Config, saveDir string, so setOptions) time.Duration {
+	now := deps.Now()
+	path, until, err := dynamicFrame(saveDir, p.bundle, now)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to pick frame:", err)
+		return scheduleTick
+	}
+	if path != p.shown && setWallpaper(ctx, deps, cfg, saveDir, path, so) == 0 {
+		p.shown = path
+	}
+
+	wait := scheduleTick
+	if d := until.Sub(now); d > 0 && d < wait {
+		wait = d
+	}
+	return wait
+}
@@ -0,0 +1,156 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestBundle(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(dir, "day.png"), 8, 8)
+	writeTestPNG(t, filepath.Join(dir, "night.png"), 8, 8)
+	manifest := `{"crossfade": "1h", "frames": [
+		{"image": "day.png", "time": "08:00"},
+		{"image": "night.png", "time": "20:00"}
+	]}`
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	return dir
+}
+
+func TestDynamicSetBlendsDuringCrossfade(t *testing.T) {
+	bundle := writeTestBundle(t)
+	saveDir := t.TempDir()
+	setter := &recordingSetter{}
+
+	run := func(at time.Time, args ...string) (int, string) {
+		var out bytes.Buffer
+		code := Main(context.Background(), args, Deps{
+			Setter:    setter,
+			Out:       &out,
+			Err:       &out,
+			ConfigDir: func() (string, error) { return t.TempDir(), nil },
+			Now:       func() time.Time { return at },
+		})
+		return code, out.String()
+	}
+
+	noon := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	if code, out := run(noon, "dynamic", "set", bundle, "-d", saveDir); code != 0 {
+		t.Fatalf("set failed: %s", out)
+	}
+	stale := filepath.Join(saveDir, ".wugo", "dynamic", "blend", "0123456789ab-50.jpg")
+	if err := os.MkdirAll(filepath.Dir(stale), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(stale, nil, 0o644); err != nil {
+		t.Fatalf("write blend: %v", err)
+	}
+	fade := time.Date(2026, 10, 16, 19, 33, 0, 0, time.Local)
+	if code, out := run(fade, "dynamic", "set", bundle, "-d", saveDir); code != 0 {
+		t.Fatalf("set failed: %s", out)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected the old transition's blend removed: %v", err)
+	}
+
+	if len(setter.paths) != 2 || filepath.Base(setter.paths[0]) != "day.png" {
+		t.Fatalf("unexpected wallpapers: %v", setter.paths)
+	}
+	blend := setter.paths[1]
+	if !strings.HasPrefix(blend, filepath.Join(saveDir, ".wugo", "dynamic", "blend")) || !strings.HasSuffix(blend, "-50.jpg") {
+		t.Fatalf("expected a 50%% blend, got %s", blend)
+	}
+	if _, err := os.Stat(blend); err != nil {
+		t.Fatalf("expected blend on disk: %v", err)
+	}
+
+	code, out := run(noon, "dynamic", "preview", bundle)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if code != 0 || len(lines) != 6 ||
+		!strings.Contains(lines[1], "Fri 12:00  Fri 19:00  day.png") ||
+		!strings.Contains(lines[2], "Fri 19:00  Fri 20:00  day.png -> night.png") {
+		t.Fatalf("unexpected preview %d:\n%s", code, out)
+	}
+}
+
+func TestDynamicExport(t *testing.T) {
+	bundle := writeTestBundle(t)
+	output := filepath.Join(t.TempDir(), "background.xml")
+
+	var out bytes.Buffer
+	code := Main(context.Background(), []string{"dynamic", "export", bundle, "-o", output}, Deps{
+		Out:       &out,
+		Err:       &out,
+		ConfigDir: func() (string, error) { return t.TempDir(), nil },
+		Now:       func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local) },
+	})
+	if code != 0 {
+		t.Fatalf("export failed: %s", out.String())
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	xml := string(data)
+	if strings.Count(xml, "<static>") != 2 || strings.Count(xml, `<transition type="overlay">`) != 2 ||
+		!strings.Contains(xml, "<hour>8</hour>") || !strings.Contains(xml, filepath.Join(bundle, "night.png")) {
+		t.Fatalf("unexpected export:\n%s", xml)
+	}
+}
+
+func TestDaemonPlaysDynamicWallpaper(t *testing.T) {
+	bundle := writeTestBundle(t)
+	saveDir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := time.Date(2026, 10, 16, 18, 59, 0, 0, time.Local)
+	setter := &recordingSetter{}
+	var waits []time.Duration
+	var out bytes.Buffer
+	deps := Deps{
+		Setter:    setter,
+		Out:       &out,
+		Err:       &out,
+		ConfigDir: func() (string, error) { return t.TempDir(), nil },
+		Now:       func() time.Time { return clock },
+		After: func(d time.Duration) <-chan time.Time {
+			waits = append(waits, d)
+			clock = clock.Add(d)
+			if len(waits) == 40 {
+				cancel()
+				return make(chan time.Time)
+			}
+			ch := make(chan time.Time, 1)
+			ch <- clock
+			return ch
+		},
+	}
+
+	if code := Main(ctx, []string{"daemon", "-d", saveDir, "--dynamic", bundle}, deps); code != 0 {
+		t.Fatalf("daemon failed: %s", out.String())
+	}
+
+	// Day until 19:06, then a new blend every 6 minutes until 19:39.
+	var names []string
+	for _, p := range setter.paths {
+		names = append(names, filepath.Base(p))
+	}
+	if len(names) != 7 || names[0] != "day.png" || !strings.HasSuffix(names[1], "-10.jpg") || !strings.HasSuffix(names[6], "-60.jpg") {
+		t.Fatalf("unexpected wallpapers: %v\n%s", names, out.String())
+	}
+	for _, w := range waits {
+		if w > time.Minute {
+			t.Fatalf("expected waits capped at a minute: %v", waits)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHooksAroundSet(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "hooks.log")
+	configDir := writeConfig(t, `{"hooks": {
+		"pre_download": [{"command": "echo pre $WUGO_SOURCE >> `+log+`"}],
+		"post_set": [
+			{"command": "echo post $WUGO_IMAGE $WUGO_TARGETS >> `+log+`"},
+			{"name": "sync", "command": "exit 4", "required": true}
+		],
+		"on_failure": [{"command": "echo failed $WUGO_ERROR >> `+log+`"}]
+	}}`)
+
+	run := func(processor *fakeProcessor, setter *fakeSetter) (int, string) {
+		var out bytes.Buffer
+		code := Main(context.Background(), []string{"https://example.com/forest.jpg"}, Deps{
+			Processor: processor,
+			Setter:    setter,
+			Out:       &out,
+			Err:       &out,
+			MkdirAll:  func(string, fs.FileMode) error { return nil },
+			HomeDir:   func() (string, error) { return t.TempDir(), nil },
+			ConfigDir: func() (string, error) { return configDir, nil },
+		})
+		return code, out.String()
+	}
+
+	code, out := run(&fakeProcessor{result: "/tmp/forest.jpg"}, &fakeSetter{})
+	if code != 1 || !strings.Contains(out, `Failed to run hook: post_set hook "sync": exit status 4`) {
+		t.Fatalf("expected the required hook to fail the set, got %d: %s", code, out)
+	}
+	if !strings.Contains(out, "Wallpaper set successfully") {
+		t.Fatalf("expected the wallpaper to be set before hooks: %s", out)
+	}
+
+	code, out = run(&fakeProcessor{result: "/tmp/forest.jpg"}, &fakeSetter{lockErr: errors.New("locked")})
+	if code != 1 {
+		t.Fatalf("expected failure, got %d: %s", code, out)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatalf("read hook log: %v", err)
+	}
+	expected := "pre https://example.com/forest.jpg\n" +
+		"post /tmp/forest.jpg desktop,lockscreen\n" +
+		"pre https://example.com/forest.jpg\n" +
+		"failed lockscreen: locked\n"
+	if string(data) != expected {
+		t.Fatalf("unexpected hook log:\n%s", data)
+	}
+}
+
+func TestRequiredPreDownloadHookCancels(t *testing.T) {
+	configDir := writeConfig(t, `{"hooks": {"pre_download": [{"command": "exit 1", "required": true}]}}`)
+	processor := &fakeProcessor{result: "/tmp/forest.jpg"}
+
+	var out bytes.Buffer
+	code := Main(context.Background(), []string{"wallhaven:forest"}, Deps{
+		Processor: processor,
+		Setter:    &fakeSetter{},
+		Out:       &out,
+		Err:       &out,
+		MkdirAll:  func(string, fs.FileMode) error { return nil },
+		HomeDir:   func() (string, error) { return t.TempDir(), nil },
+		ConfigDir: func() (string, error) { return configDir, nil },
+	})
+	if code != 1 || processor.input != "" {
+		t.Fatalf("expected the download to be cancelled, got %d: %s", code, out.String())
+	}
+}
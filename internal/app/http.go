@@ -0,0 +1,112 @@
+package app
+
+import (
+	"net/http"
+
+	"wugo/internal/config"
+	"wugo/internal/httpclient"
+	"wugo/internal/image"
+	"wugo/internal/library"
+)
+
+func newProcessor(opts Options, cfg config.Config, deps Deps) (*image.Processor, error) {
+	rules, err := headerRules(opts, cfg.HTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	clientCfg, err := clientConfig(opts, cfg.HTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := httpclient.New(clientCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dial, err := httpclient.NewDialer(clientCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	proc := image.NewProcessor(client, nil,
+		image.WithHeaders(rules),
+		image.WithRefresh(opts.Refresh),
+		image.WithRecorder(library.Recorder{Now: deps.Now, Warnings: deps.Err}),
+	)
+	registerSources(proc, opts, cfg.Sources, remote{dial: dial, offline: clientCfg.Offline})
+
+	return proc, nil
+}
+
+// clientConfig merges config transport settings with command-line flags.
+func clientConfig(opts Options, cfg config.HTTP) (httpclient.Config, error) {
+	clientCfg := httpclient.Config{
+		Proxy:   cfg.Proxy,
+		CAFile:  cfg.CAFile,
+		Offline: cfg.Offline || opts.Offline,
+	}
+	if opts.Proxy != "" {
+		clientCfg.Proxy = opts.Proxy
+	}
+	if opts.CAFile != "" {
+		clientCfg.CAFile = opts.CAFile
+	}
+
+	cookieFile := opts.CookieFile
+	if cookieFile == "" {
+		cookieFile = cfg.CookieFile
+	}
+	if cookieFile != "" {
+		jar, err := image.LoadCookieFile(cookieFile)
+		if err != nil {
+			return httpclient.Config{}, err
+		}
+		clientCfg.Jar = jar
+	}
+
+	return clientCfg, nil
+}
+
+// headerRules merges config headers with command-line flags, flags winning.
+func headerRules(opts Options, cfg config.HTTP) (image.HeaderRules, error) {
+	rules := image.HeaderRules{Default: http.Header{}}
+
+	for key, value := range cfg.Headers {
+		rules.Default.Set(key, value)
+	}
+	if cfg.UserAgent != "" {
+		rules.Default.Set("User-Agent", cfg.UserAgent)
+	}
+	if cfg.Referer != "" {
+		rules.Default.Set("Referer", cfg.Referer)
+	}
+
+	for _, spec := range opts.Headers {
+		key, value, err := image.ParseHeader(spec)
+		if err != nil {
+			return image.HeaderRules{}, err
+		}
+		rules.Default.Set(key, value)
+	}
+	if opts.UserAgent != "" {
+		rules.Default.Set("User-Agent", opts.UserAgent)
+	}
+	if opts.Referer != "" {
+		rules.Default.Set("Referer", opts.Referer)
+	}
+
+	if len(cfg.Hosts) > 0 {
+		rules.Hosts = make(map[string]http.Header, len(cfg.Hosts))
+		for host, headers := range cfg.Hosts {
+			header := http.Header{}
+			for key, value := range headers {
+				header.Set(key, value)
+			}
+			rules.Hosts[host] = header
+		}
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"wugo/internal/config"
+)
+
+func TestParseArgsHTTPFlags(t *testing.T) {
+	opts, input, err := ParseArgs([]string{
+		"--header", "X-One: 1",
+		"--header", "X-Two:2",
+		"--user-agent", "wugo-test",
+		"--referer", "https://example.com/",
+		"--cookie-file", "/tmp/cookies.txt",
+		"https://example.com/a.jpg",
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if input != "https://example.com/a.jpg" {
+		t.Fatalf("unexpected input: %s", input)
+	}
+	if len(opts.Headers) != 2 || opts.UserAgent != "wugo-test" || opts.Referer != "https://example.com/" || opts.CookieFile != "/tmp/cookies.txt" {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+}
+
+func TestHeaderRulesFlagsOverrideConfig(t *testing.T) {
+	cfg := config.HTTP{
+		UserAgent: "from-config",
+		Referer:   "https://config.test/",
+		Headers:   map[string]string{"x-one": "config"},
+		Hosts:     map[string]map[string]string{"example.com": {"x-host": "yes"}},
+	}
+	opts := Options{Headers: []string{"X-One: flag"}, UserAgent: "from-flag"}
+
+	rules, err := headerRules(opts, cfg)
+	if err != nil {
+		t.Fatalf("header rules: %v", err)
+	}
+	if rules.Default.Get("User-Agent") != "from-flag" {
+		t.Fatalf("expected flag user agent, got %q", rules.Default.Get("User-Agent"))
+	}
+	if rules.Default.Get("Referer") != "https://config.test/" {
+		t.Fatalf("expected config referer, got %q", rules.Default.Get("Referer"))
+	}
+	if rules.Default.Get("X-One") != "flag" {
+		t.Fatalf("expected flag header, got %q", rules.Default.Get("X-One"))
+	}
+	if rules.Hosts["example.com"].Get("X-Host") != "yes" {
+		t.Fatalf("missing host rule: %+v", rules.Hosts)
+	}
+}
+
+func TestHeaderRulesInvalidFlag(t *testing.T) {
+	if _, err := headerRules(Options{Headers: []string{"broken"}}, config.HTTP{}); err == nil {
+		t.Fatal("expected invalid header error")
+	}
+}
+
+func TestClientConfigFlagsOverrideConfig(t *testing.T) {
+	cfg := config.HTTP{Proxy: "http://config-proxy:3128", CAFile: "/etc/config-ca.pem"}
+	opts := Options{Proxy: "socks5://127.0.0.1:1080", Offline: true}
+
+	got, err := clientConfig(opts, cfg)
+	if err != nil {
+		t.Fatalf("client config: %v", err)
+	}
+	if got.Proxy != "socks5://127.0.0.1:1080" {
+		t.Fatalf("expected flag proxy, got %s", got.Proxy)
+	}
+	if got.CAFile != "/etc/config-ca.pem" {
+		t.Fatalf("expected config CA file, got %s", got.CAFile)
+	}
+	if !got.Offline {
+		t.Fatal("expected offline mode")
+	}
+}
+
+func TestOfflineRefusesSFTP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	var accepted atomic.Int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			_ = conn.Close()
+		}
+	}()
+
+	var out bytes.Buffer
+	code := Main(context.Background(), []string{"--offline", "sftp://user@" + ln.Addr().String() + "/photos/a.jpg"}, Deps{
+		Setter:    &fakeSetter{},
+		Out:       &out,
+		Err:       &out,
+		HomeDir:   func() (string, error) { return t.TempDir(), nil },
+		ConfigDir: func() (string, error) { return t.TempDir(), nil },
+	})
+	if code != 1 || !strings.Contains(out.String(), "offline mode") {
+		t.Fatalf("expected offline failure, got %d: %s", code, out.String())
+	}
+	if n := accepted.Load(); n != 0 {
+		t.Fatalf("expected no connection, got %d", n)
+	}
+}
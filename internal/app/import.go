@@ -0,0 +1,242 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"wugo/internal/image"
+	"wugo/internal/library"
+)
+
+type importResult struct {
+	rel       string
+	dest      string
+	duplicate string
+	err       error
+}
+
+// runImport brings the images of a directory into the library, skipping
+// files whose contents are already stored.
+func runImport(ctx context.Context, args []string, deps Deps) int {
+	fs := flag.NewFlagSet("wugo import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("d", "", "Save directory")
+	copyFiles := fs.Bool("copy", false, "Copy files into the library (default)")
+	moveFiles := fs.Bool("move", false, "Move files into the library")
+	linkFiles := fs.Bool("link", false, "Symlink files from the library")
+	recursive := fs.Bool("recursive", false, "Import subdirectories too")
+	fs.BoolVar(recursive, "r", false, "Import subdirectories too")
+	folderTags := fs.Bool("folder-tags", false, "Tag images with the names of their subdirectories")
+	jobs := fs.Int("jobs", runtime.NumCPU(), "Number of files imported in parallel")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return commandUsage(deps, "import", err)
+	}
+	if len(positional) != 1 {
+		return commandUsage(deps, "import", fmt.Errorf("expected one directory"))
+	}
+	if *jobs < 1 {
+		return commandUsage(deps, "import", fmt.Errorf("jobs must be at least 1"))
+	}
+
+	transfer := image.TransferCopy
+	modes := 0
+	for _, mode := range []struct {
+		set      bool
+		transfer image.Transfer
+	}{{*copyFiles, image.TransferCopy}, {*moveFiles, image.TransferMove}, {*linkFiles, image.TransferLink}} {
+		if mode.set {
+			transfer = mode.transfer
+			modes++
+		}
+	}
+	if modes > 1 {
+		return commandUsage(deps, "import", fmt.Errorf("use only one of --copy, --move and --link"))
+	}
+
+	root, err := filepath.Abs(positional[0])
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to resolve import directory:", err)
+		return 1
+	}
+	files, notImages, err := importFiles(root, *recursive)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to read import directory:", err)
+		return 1
+	}
+
+	saveDir, err := resolveSaveDir(*dir, deps.HomeDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to resolve save directory:", err)
+		return 1
+	}
+	if err := deps.MkdirAll(saveDir, 0o755); err != nil {
+		fmt.Fprintln(deps.Err, "Failed to create directory:", err)
+		return 1
+	}
+	ix, code := lockLibrary(deps, saveDir)
+	if ix == nil {
+		return code
+	}
+	defer ix.Unlock()
+
+	// Hash everything first so duplicates are decided in file order, then
+	// import the remaining files.
+	results := make([]importResult, len(files))
+	hashes := make([]string, len(files))
+	parallel(ctx, *jobs, len(files), func(i int) {
+		results[i].rel = files[i]
+		hashes[i], results[i].err = library.HashFile(filepath.Join(root, files[i]))
+	})
+
+	seen := make(map[string]string, len(ix.Entries))
+	for _, e := range ix.Entries {
+		seen[e.Hash] = e.File
+	}
+	for i := range results {
+		if results[i].err != nil || hashes[i] == "" {
+			continue
+		}
+		if other, ok := seen[hashes[i]]; ok {
+			results[i].duplicate = other
+			continue
+		}
+		seen[hashes[i]] = files[i]
+	}
+
+	var mu sync.Mutex
+	proc := image.NewProcessor(nil, nil)
+	parallel(ctx, *jobs, len(files), func(i int) {
+		r := &results[i]
+		if r.err != nil || r.duplicate != "" || hashes[i] == "" {
+			return
+		}
+		src := filepath.Join(root, r.rel)
+		dest, err := proc.Import(src, saveDir, transfer)
+		if err != nil {
+			r.err = err
+			return
+		}
+		e, err := library.Describe(dest)
+		if err != nil {
+			r.err = err
+			return
+		}
+		e.SourceURL = src
+
+		mu.Lock()
+		defer mu.Unlock()
+		if added := ix.Insert(dest, e, deps.Now()); added != nil && *folderTags {
+			ix.AddTags(*added, folderTagsOf(r.rel)...)
+		}
+		r.dest = filepath.Base(dest)
+	})
+
+	imported, duplicates, failed := 0, 0, 0
+	for _, r := range results {
+		switch {
+		case r.err == nil && r.duplicate == "" && r.dest == "":
+			// Not reached because the import was cancelled.
+		case r.err != nil:
+			fmt.Fprintf(deps.Err, "Failed to import %s: %v\n", r.rel, r.err)
+			failed++
+		case r.duplicate != "":
+			fmt.Fprintf(deps.Out, "skipped  %s (duplicate of %s)\n", r.rel, r.duplicate)
+			duplicates++
+		default:
+			fmt.Fprintf(deps.Out, "imported %s -> %s\n", r.rel, r.dest)
+			imported++
+		}
+	}
+
+	if err := ix.Save(); err != nil {
+		fmt.Fprintln(deps.Err, "Failed to save library:", err)
+		return 1
+	}
+
+	fmt.Fprintf(deps.Out, "Imported %d, skipped %d (%d duplicates, %d not images), failed %d\n",
+		imported, duplicates+notImages, duplicates, notImages, failed)
+	if failed > 0 || ctx.Err() != nil {
+		return 1
+	}
+	return 0
+}
+
+// importFiles lists the images below root relative to it, and counts the
+// other files. Hidden directories are skipped.
+func importFiles(root string, recursive bool) ([]string, int, error) {
+	var files []string
+	notImages := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (!recursive || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		if !image.IsImageFile(d.Name()) {
+			notImages++
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	return files, notImages, err
+}
+
+// folderTagsOf turns the directories of rel into tags, e.g. "Nature/Dark"
+// becomes "nature" and "dark".
+func folderTagsOf(rel string) []string {
+	dir := filepath.Dir(rel)
+	if dir == "." {
+		return nil
+	}
+	return strings.Split(filepath.ToSlash(dir), "/")
+}
+
+// parallel calls fn for 0..n-1 on at most jobs goroutines. Indexes not yet
+// started when ctx is cancelled are skipped.
+func parallel(ctx context.Context, jobs, n int, fn func(i int)) {
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for range min(jobs, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := range n {
+		select {
+		case queue <- i:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	src := t.TempDir()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "Nature", "Dark"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeTestPNG(t, filepath.Join(src, "top.png"), 8, 8)
+	writeTestPNG(t, filepath.Join(src, "Nature", "Dark", "forest.png"), 9, 9)
+	writeTestPNG(t, filepath.Join(src, "Nature", "forest-copy.png"), 9, 9)
+	if err := os.WriteFile(filepath.Join(src, "notes.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	run := func(args ...string) (int, string) {
+		var out bytes.Buffer
+		code := Main(context.Background(), args, Deps{Out: &out, Err: &out})
+		return code, out.String()
+	}
+
+	code, out := run("import", src, "-d", dir)
+	if code != 0 || !strings.Contains(out, "Imported 1, skipped 1 (0 duplicates, 1 not images), failed 0") {
+		t.Fatalf("unexpected flat import %d: %s", code, out)
+	}
+
+	code, out = run("import", src, "-d", dir, "--recursive", "--folder-tags", "--jobs", "2")
+	if code != 0 || !strings.Contains(out, "Imported 1, skipped 3 (2 duplicates, 1 not images), failed 0") {
+		t.Fatalf("unexpected recursive import %d: %s", code, out)
+	}
+	if !strings.Contains(out, "skipped  top.png (duplicate of top_") {
+		t.Fatalf("expected top.png to be a duplicate of the stored copy: %s", out)
+	}
+
+	code, out = run("list", "-d", dir, "--tag", "nature", "--tag", "dark")
+	if code != 0 || !strings.Contains(out, "forest") {
+		t.Fatalf("expected folder tags on imported image %d: %s", code, out)
+	}
+	if _, err := os.Stat(filepath.Join(src, "top.png")); err != nil {
+		t.Fatalf("expected copy to keep originals: %v", err)
+	}
+
+	if code, _ := run("import", src, "--copy", "--move"); code != 2 {
+		t.Fatalf("expected exit code 2 for conflicting modes, got %d", code)
+	}
+}
+
+func TestImportMove(t *testing.T) {
+	src := t.TempDir()
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(src, "a.png"), 8, 8)
+
+	var out bytes.Buffer
+	code := Main(context.Background(), []string{"import", "--move", "-d", dir, src}, Deps{Out: &out, Err: &out})
+	if code != 0 {
+		t.Fatalf("import failed: %s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(src, "a.png")); !os.IsNotExist(err) {
+		t.Fatalf("expected original moved, got %v", err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "a_*.png"))
+	if len(matches) != 1 {
+		t.Fatalf("expected moved file in library, got %v", matches)
+	}
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"wugo/internal/library"
+)
+
+func runTag(_ context.Context, args []string, deps Deps) int {
+	if len(args) == 0 {
+		return commandUsage(deps, "tag", fmt.Errorf("expected subcommand: add, rm or ls"))
+	}
+	sub := args[0]
+
+	fs := flag.NewFlagSet("wugo tag", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("d", "", "Save directory")
+	positional, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		return commandUsage(deps, "tag", err)
+	}
+
+	switch sub {
+	case "add", "rm":
+		if len(positional) < 2 {
+			return commandUsage(deps, "tag", fmt.Errorf("expected a file and at least one tag"))
+		}
+	case "ls":
+		if len(positional) > 1 {
+			return commandUsage(deps, "tag", fmt.Errorf("expected at most one file"))
+		}
+	default:
+		return commandUsage(deps, "tag", fmt.Errorf("unknown subcommand: %s", sub))
+	}
+
+	ix, code := lockLibrary(deps, *dir)
+	if ix == nil {
+		return code
+	}
+	defer ix.Unlock()
+
+	if sub == "ls" {
+		if len(positional) == 0 {
+			counts := ix.TagCounts()
+			tags := make([]string, 0, len(counts))
+			for tag := range counts {
+				tags = append(tags, tag)
+			}
+			slices.Sort(tags)
+			for _, tag := range tags {
+				fmt.Fprintf(deps.Out, "%s\t%d\n", tag, counts[tag])
+			}
+			return 0
+		}
+		e, err := ix.Resolve(positional[0])
+		if err != nil {
+			fmt.Fprintln(deps.Err, err)
+			return 1
+		}
+		for _, tag := range ix.LabelsOf(*e).Tags {
+			fmt.Fprintln(deps.Out, tag)
+		}
+		return 0
+	}
+
+	e, err := ix.Resolve(positional[0])
+	if err != nil {
+		fmt.Fprintln(deps.Err, err)
+		return 1
+	}
+	if sub == "add" {
+		ix.AddTags(*e, positional[1:]...)
+	} else {
+		ix.RemoveTags(*e, positional[1:]...)
+	}
+	if err := ix.Save(); err != nil {
+		fmt.Fprintln(deps.Err, "Failed to save library:", err)
+		return 1
+	}
+
+	fmt.Fprintf(deps.Out, "%s: %s\n", e.File, cmp.Or(strings.Join(ix.LabelsOf(*e).Tags, ", "), "no tags"))
+	return 0
+}
+
+// runFav stars or unstars an image, the current wallpaper by default.
+func runFav(favorite bool) commandFunc {
+	name := "fav"
+	if !favorite {
+		name = "unfav"
+	}
+
+	return func(_ context.Context, args []string, deps Deps) int {
+		fs := flag.NewFlagSet("wugo "+name, flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		dir := fs.String("d", "", "Save directory")
+		positional, err := parseInterspersed(fs, args)
+		if err != nil {
+			return commandUsage(deps, name, err)
+		}
+		if len(positional) > 1 {
+			return commandUsage(deps, name, fmt.Errorf("expected at most one file"))
+		}
+		target := library.CurrentAlias
+		if len(positional) == 1 {
+			target = positional[0]
+		}
+
+		ix, code := lockLibrary(deps, *dir)
+		if ix == nil {
+			return code
+		}
+		defer ix.Unlock()
+
+		e, err := ix.Resolve(target)
+		if err != nil {
+			fmt.Fprintln(deps.Err, err)
+			return 1
+		}
+		ix.SetFavorite(*e, favorite)
+		if err := ix.Save(); err != nil {
+			fmt.Fprintln(deps.Err, "Failed to save library:", err)
+			return 1
+		}
+
+		if favorite {
+			fmt.Fprintln(deps.Out, "Added to favorites:", e.File)
+		} else {
+			fmt.Fprintln(deps.Out, "Removed from favorites:", e.File)
+		}
+		return 0
+	}
+}
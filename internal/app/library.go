@@ -0,0 +1,267 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"wugo/internal/imaging"
+	"wugo/internal/library"
+)
+
+const timeLayout = "2006-01-02 15:04"
+
+func runList(_ context.Context, args []string, deps Deps) int {
+	fs := flag.NewFlagSet("wugo list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("d", "", "Save directory")
+	sortKey := fs.String("sort", "added", "Sort by added, set, times, name, size or resolution")
+	reverse := fs.Bool("reverse", false, "Reverse the sort order")
+	limit := fs.Int("limit", 0, "Show at most this many images")
+	filters := addFilterFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return commandUsage(deps, "list", err)
+	}
+
+	q, err := filters.query()
+	if err != nil {
+		return commandUsage(deps, "list", err)
+	}
+	q.Sort, q.Reverse, q.Limit = *sortKey, *reverse, *limit
+
+	ix, code := openLibrary(deps, *dir)
+	if ix == nil {
+		return code
+	}
+
+	entries, err := ix.List(q)
+	if err != nil {
+		return commandUsage(deps, "list", err)
+	}
+
+	tw := tabwriter.NewWriter(deps.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tRESOLUTION\tFORMAT\tSIZE\tADDED\tSET\tTAGS\tSOURCE")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			e.File, resolution(e), e.Format, humanSize(e.Size), e.Added.Local().Format(timeLayout), e.TimesSet,
+			labelSummary(ix.LabelsOf(e)), cmp.Or(e.Source, e.SourceURL))
+	}
+	_ = tw.Flush()
+	return 0
+}
+
+func runInfo(_ context.Context, args []string, deps Deps) int {
+	fs := flag.NewFlagSet("wugo info", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("d", "", "Save directory")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return commandUsage(deps, "info", err)
+	}
+	if len(positional) != 1 {
+		return commandUsage(deps, "info", fmt.Errorf("expected one file"))
+	}
+
+	ix, code := openLibrary(deps, *dir)
+	if ix == nil {
+		return code
+	}
+
+	e, err := ix.Resolve(positional[0])
+	if err != nil {
+		fmt.Fprintln(deps.Err, err)
+		return 1
+	}
+
+	tw := tabwriter.NewWriter(deps.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Path:\t%s\n", ix.Path(*e))
+	fmt.Fprintf(tw, "Source URL:\t%s\n", e.SourceURL)
+	if e.Source != "" {
+		fmt.Fprintf(tw, "Source:\t%s\n", e.Source)
+	}
+	if e.Title != "" {
+		fmt.Fprintf(tw, "Title:\t%s\n", e.Title)
+	}
+	fmt.Fprintf(tw, "Resolution:\t%s\n", resolution(*e))
+	fmt.Fprintf(tw, "Format:\t%s\n", e.Format)
+	fmt.Fprintf(tw, "Size:\t%s (%d bytes)\n", humanSize(e.Size), e.Size)
+	fmt.Fprintf(tw, "SHA-256:\t%s\n", e.Hash)
+	fmt.Fprintf(tw, "Added:\t%s\n", e.Added.Local().Format(time.RFC3339))
+	labels := ix.LabelsOf(*e)
+	if len(labels.Tags) > 0 {
+		fmt.Fprintf(tw, "Tags:\t%s\n", strings.Join(labels.Tags, ", "))
+	}
+	fmt.Fprintf(tw, "Favorite:\t%t\n", labels.Favorite)
+	fmt.Fprintf(tw, "Times set:\t%d\n", e.TimesSet)
+	if !e.LastSet.IsZero() {
+		fmt.Fprintf(tw, "Last set:\t%s\n", e.LastSet.Local().Format(time.RFC3339))
+	}
+	_ = tw.Flush()
+	return 0
+}
+
+func runLibrary(_ context.Context, args []string, deps Deps) int {
+	if len(args) == 0 || args[0] != "rescan" {
+		return commandUsage(deps, "library", fmt.Errorf("expected subcommand: rescan"))
+	}
+
+	fs := flag.NewFlagSet("wugo library rescan", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("d", "", "Save directory")
+	if err := fs.Parse(args[1:]); err != nil {
+		return commandUsage(deps, "library", err)
+	}
+
+	ix, code := lockLibrary(deps, *dir)
+	if ix == nil {
+		return code
+	}
+	defer ix.Unlock()
+
+	result, err := ix.Rescan(deps.Now())
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to rescan library:", err)
+		return 1
+	}
+	if err := ix.Save(); err != nil {
+		fmt.Fprintln(deps.Err, "Failed to save library:", err)
+		return 1
+	}
+
+	for _, f := range result.Added {
+		fmt.Fprintln(deps.Out, "+", f)
+	}
+	for _, f := range result.Removed {
+		fmt.Fprintln(deps.Out, "-", f)
+	}
+	for _, f := range result.Updated {
+		fmt.Fprintln(deps.Out, "~", f)
+	}
+	fmt.Fprintf(deps.Out, "Added %d, removed %d, updated %d (%d images)\n",
+		len(result.Added), len(result.Removed), len(result.Updated), len(ix.Entries))
+	return 0
+}
+
+// openLibrary loads the index of the save directory; on failure it prints
+// the error and returns the exit code.
+func openLibrary(deps Deps, dir string) (*library.Index, int) {
+	saveDir, err := resolveSaveDir(dir, deps.HomeDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to resolve save directory:", err)
+		return nil, 1
+	}
+
+	ix, err := library.Open(saveDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to open library:", err)
+		return nil, 1
+	}
+	return ix, 0
+}
+
+// lockLibrary is openLibrary for commands that change the index. The
+// caller must Unlock it.
+func lockLibrary(deps Deps, dir string) (*library.Index, int) {
+	saveDir, err := resolveSaveDir(dir, deps.HomeDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to resolve save directory:", err)
+		return nil, 1
+	}
+
+	ix, err := library.Lock(saveDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to open library:", err)
+		return nil, 1
+	}
+	return ix, 0
+}
+
+func commandUsage(deps Deps, name string, err error) int {
+	fmt.Fprintf(deps.Err, "wugo %s: %v\n", name, err)
+	usage(deps.Err)
+	return 2
+}
+
+// filterFlags are the library filters shared by list, random and daemon.
+type filterFlags struct {
+	format   *string
+	source   *string
+	minRes   *string
+	tags     stringList
+	favorite *bool
+}
+
+func addFilterFlags(fs *flag.FlagSet) *filterFlags {
+	f := &filterFlags{
+		format:   fs.String("format", "", "Only images of this format, e.g. png"),
+		source:   fs.String("source", "", "Only images from this source or URL substring"),
+		minRes:   fs.String("min", "", "Minimum resolution, e.g. 1920x1080"),
+		favorite: fs.Bool("fav", false, "Only favorite images"),
+	}
+	fs.Var(&f.tags, "tag", "Only images with this tag (repeatable)")
+	return f
+}
+
+func (f *filterFlags) query() (library.Query, error) {
+	minW, minH, err := imaging.ParseResolution(*f.minRes)
+	if err != nil {
+		return library.Query{}, err
+	}
+
+	return library.Query{
+		Format:    *f.format,
+		Source:    *f.source,
+		MinWidth:  minW,
+		MinHeight: minH,
+		Tags:      splitTags(f.tags...),
+		Favorite:  *f.favorite,
+	}, nil
+}
+
+// splitTags splits comma-separated tag lists.
+func splitTags(values ...string) []string {
+	var tags []string
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+func labelSummary(l library.Labels) string {
+	tags := strings.Join(l.Tags, ",")
+	if l.Favorite {
+		tags = strings.TrimSuffix("★ "+tags, " ")
+	}
+	return cmp.Or(tags, "-")
+}
+
+func resolution(e library.Entry) string {
+	if e.Width == 0 || e.Height == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%dx%d", e.Width, e.Height)
+}
+
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
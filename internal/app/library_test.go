@@ -0,0 +1,80 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestPNG(t *testing.T, path string, w, h int) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+}
+
+// writeConfig writes data as the config file of a new config directory
+// and returns the directory.
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	configDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(configDir, "wugo"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "wugo", "config.json"), []byte(data), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return configDir
+}
+
+func TestLibraryCommands(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(dir, "small.png"), 640, 480)
+	writeTestPNG(t, filepath.Join(dir, "large.png"), 1920, 1080)
+
+	run := func(args ...string) (int, string) {
+		var out bytes.Buffer
+		code := Main(context.Background(), args, Deps{
+			Out: &out,
+			Err: &out,
+			Now: func() time.Time { return time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC) },
+		})
+		return code, out.String()
+	}
+
+	code, out := run("library", "rescan", "-d", dir)
+	if code != 0 || !strings.Contains(out, "Added 2, removed 0, updated 0") {
+		t.Fatalf("unexpected rescan result %d: %s", code, out)
+	}
+
+	code, out = run("list", "-d", dir, "--min", "1920x1080")
+	if code != 0 || !strings.Contains(out, "large.png") || strings.Contains(out, "small.png") {
+		t.Fatalf("unexpected list result %d: %s", code, out)
+	}
+
+	code, out = run("info", "small.png", "-d", dir)
+	if code != 0 || !strings.Contains(out, "640x480") || !strings.Contains(out, "png") {
+		t.Fatalf("unexpected info result %d: %s", code, out)
+	}
+
+	if code, _ := run("info", "missing.png", "-d", dir); code != 1 {
+		t.Fatalf("expected exit code 1 for unknown file, got %d", code)
+	}
+	if code, _ := run("list", "-d", dir, "--min", "big"); code != 2 {
+		t.Fatalf("expected exit code 2 for bad resolution, got %d", code)
+	}
+	if code, _ := run("library"); code != 2 {
+		t.Fatalf("expected exit code 2 without subcommand, got %d", code)
+	}
+}
@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wugo/internal/config"
+	"wugo/internal/imaging"
+	"wugo/internal/library"
+	"wugo/internal/notify"
+)
+
+// notifyTimeout is how long a notification stays up and how long wugo
+// waits for one of its actions.
+const notifyTimeout = 10 * time.Second
+
+const (
+	actionUndo     = "undo"
+	actionFavorite = "favorite"
+)
+
+// currentWallpaper returns the path of the wallpaper the library says is
+// set, or "" if there is none.
+func currentWallpaper(saveDir string) string {
+	ix, err := library.Open(saveDir)
+	if err != nil {
+		return ""
+	}
+	if e, ok := ix.Current(); ok {
+		return ix.Path(*e)
+	}
+	return ""
+}
+
+// undoSet returns how to undo setting path. It restores what the targets
+// showed before when the backend could read them back, and otherwise sets
+// the wallpaper the library last set. It returns nil when there is nothing
+// to go back to.
+func undoSet(deps Deps, cfg config.Config, saveDir, path string, shown []snapshot) func(context.Context) error {
+	if restorable(shown) {
+		return func(ctx context.Context) error {
+			if err := restoreSnapshots(ctx, shown); err != nil {
+				return err
+			}
+			if image := shown[0].image; image != "" {
+				if err := library.MarkSet(saveDir, image, deps.Now()); err != nil {
+					fmt.Fprintln(deps.Err, "Failed to update library:", err)
+				}
+			}
+			return nil
+		}
+	}
+	if shown != nil {
+		return nil
+	}
+	previous := currentWallpaper(saveDir)
+	if previous == "" || previous == path {
+		return nil
+	}
+	return func(ctx context.Context) error {
+		if setWallpaper(ctx, deps, cfg, saveDir, previous, setOptions{}) != 0 {
+			return fmt.Errorf("could not set %s", previous)
+		}
+		return nil
+	}
+}
+
+// notifySet shows that path was set and, with actions, handles Undo and
+// Favorite. undo is nil when there is nothing to undo. Failures are
+// reported but do not fail the set.
+func notifySet(ctx context.Context, deps Deps, saveDir, path string, undo func(context.Context) error, actions bool) {
+	conn, err := deps.SessionBus()
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to send notification:", err)
+		return
+	}
+	defer conn.Close()
+
+	n := notify.Notification{
+		AppName: "wugo",
+		Summary: "Wallpaper set",
+		Body:    filepath.Base(path),
+		Timeout: int32(notifyTimeout / time.Millisecond),
+	}
+	var entry *library.Entry
+	if ix, err := library.Open(saveDir); err == nil {
+		entry, _ = ix.Get(path)
+	}
+	if entry != nil {
+		n.Body = notificationBody(*entry)
+	}
+	if img, _, err := imaging.Decode(path); err == nil {
+		n.Image = img
+	}
+	if undo != nil {
+		n.Actions = append(n.Actions, notify.Action{Key: actionUndo, Label: "Undo"})
+	}
+	if entry != nil && actions {
+		n.Actions = append(n.Actions, notify.Action{Key: actionFavorite, Label: "Favorite"})
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	action, err := notify.Show(waitCtx, conn, n)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to send notification:", err)
+		return
+	}
+
+	switch action {
+	case actionUndo:
+		if err := undo(context.WithoutCancel(ctx)); err != nil {
+			fmt.Fprintln(deps.Err, "Failed to undo:", err)
+			return
+		}
+		fmt.Fprintln(deps.Out, "Restored previous wallpaper")
+	case actionFavorite:
+		favoriteSet(deps, saveDir, path)
+	}
+}
+
+// favoriteSet marks path as a favorite. The library is read again under
+// its lock, as it may have changed while the notification was up.
+func favoriteSet(deps Deps, saveDir, path string) {
+	ix, err := library.Lock(saveDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to open library:", err)
+		return
+	}
+	defer ix.Unlock()
+	e, ok := ix.Get(path)
+	if !ok {
+		fmt.Fprintln(deps.Err, "Failed to add to favorites: not in library:", path)
+		return
+	}
+	ix.SetFavorite(*e, true)
+	if err := ix.Save(); err != nil {
+		fmt.Fprintln(deps.Err, "Failed to save library:", err)
+		return
+	}
+	fmt.Fprintln(deps.Out, "Added to favorites:", e.File)
+}
+
+// notificationBody is the title and source of e, falling back to its file
+// name.
+func notificationBody(e library.Entry) string {
+	var lines []string
+	if e.Title != "" {
+		lines = append(lines, e.Title)
+	}
+	if e.SourceURL != "" {
+		lines = append(lines, e.SourceURL)
+	}
+	if len(lines) == 0 {
+		return filepath.Base(e.File)
+	}
+	return strings.Join(lines, "\n")
+}
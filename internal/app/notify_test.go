@@ -0,0 +1,222 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"wugo/internal/dbustest"
+	"wugo/internal/library"
+	"wugo/internal/wallpaper"
+)
+
+// notificationServer answers every notification with action, or closes it
+// when action is empty. onNotify runs before the answer is sent.
+type notificationServer struct {
+	conn     *dbus.Conn
+	mu       sync.Mutex
+	action   string
+	onNotify func()
+	bodies   []string
+	actions  int
+}
+
+func (s *notificationServer) Notify(_ string, _ uint32, _, _, body string, actions []string,
+	_ map[string]dbus.Variant, _ int32) (uint32, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies = append(s.bodies, body)
+	s.actions += len(actions) / 2
+	id := uint32(len(s.bodies))
+	if s.onNotify != nil {
+		s.onNotify()
+	}
+	const iface = "org.freedesktop.Notifications"
+	if s.action != "" && len(actions) > 0 {
+		_ = s.conn.Emit("/org/freedesktop/Notifications", iface+".ActionInvoked", id, s.action)
+	} else {
+		_ = s.conn.Emit("/org/freedesktop/Notifications", iface+".NotificationClosed", id, uint32(2))
+	}
+	return id, nil
+}
+
+func (s *notificationServer) answer(action string, onNotify func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.action, s.onNotify = action, onNotify
+}
+
+// startNotificationServer serves notifications on a private bus and
+// returns the server and the bus address.
+func startNotificationServer(t *testing.T) (*notificationServer, string) {
+	t.Helper()
+	address := dbustest.Start(t)
+	server := &notificationServer{conn: dbustest.Connect(t, address)}
+	if err := server.conn.Export(server, "/org/freedesktop/Notifications", "org.freedesktop.Notifications"); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if _, err := server.conn.RequestName("org.freedesktop.Notifications", dbus.NameFlagDoNotQueue); err != nil {
+		t.Fatalf("request name: %v", err)
+	}
+	return server, address
+}
+
+func runNotify(t *testing.T, setter wallpaper.Setter, address, dir string, args ...string) (int, string) {
+	t.Helper()
+	var out bytes.Buffer
+	code := Main(context.Background(), append(args, "-nm", "-d", dir), Deps{
+		Setter:     setter,
+		Out:        &out,
+		Err:        &out,
+		ConfigDir:  func() (string, error) { return t.TempDir(), nil },
+		SessionBus: func() (*dbus.Conn, error) { return dbus.Connect(address) },
+	})
+	return code, out.String()
+}
+
+func TestNotifyActions(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(dir, "dawn.png"), 8, 8)
+	writeTestPNG(t, filepath.Join(dir, "dusk.png"), 9, 9)
+	server, address := startNotificationServer(t)
+
+	setter := &recordingSetter{}
+	run := func(args ...string) string {
+		t.Helper()
+		code, out := runNotify(t, setter, address, dir, args...)
+		if code != 0 {
+			t.Fatalf("%v failed: %s", args, out)
+		}
+		return out
+	}
+
+	run(filepath.Join(dir, "dawn.png"))
+	server.answer(actionUndo, nil)
+	run("--notify", filepath.Join(dir, "dusk.png"))
+	if len(setter.paths) != 3 || filepath.Base(setter.paths[2]) != "dawn.png" {
+		t.Fatalf("expected undo to restore dawn.png, got %v", setter.paths)
+	}
+	server.mu.Lock()
+	if len(server.bodies) != 1 || !strings.HasSuffix(server.bodies[0], "dusk.png") {
+		t.Fatalf("unexpected notifications: %q", server.bodies)
+	}
+	server.mu.Unlock()
+
+	// The library changes while the notification is up; favoriting must
+	// not write back the copy read before.
+	server.answer(actionFavorite, func() {
+		ix, err := library.Lock(dir)
+		if err != nil {
+			t.Errorf("lock library: %v", err)
+			return
+		}
+		defer ix.Unlock()
+		if e, ok := ix.Get(filepath.Join(dir, "dawn.png")); ok {
+			ix.AddTags(*e, "morning")
+		}
+		if err := ix.Save(); err != nil {
+			t.Errorf("save library: %v", err)
+		}
+	})
+	run("--notify", filepath.Join(dir, "dusk.png"))
+	ix, err := library.Open(dir)
+	if err != nil {
+		t.Fatalf("open library: %v", err)
+	}
+	e, ok := ix.Get(filepath.Join(dir, "dusk.png"))
+	if !ok || !ix.LabelsOf(*e).Favorite {
+		t.Fatal("expected dusk.png to be a favorite")
+	}
+	e, ok = ix.Get(filepath.Join(dir, "dawn.png"))
+	if !ok || len(ix.LabelsOf(*e).Tags) != 1 {
+		t.Fatal("expected the tag added meanwhile to be kept")
+	}
+}
+
+func TestNotifyUndoRestoresShownWallpaper(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(dir, "dawn.png"), 8, 8)
+	writeTestPNG(t, filepath.Join(dir, "dusk.png"), 9, 9)
+	server, address := startNotificationServer(t)
+
+	// The library last set dawn.png, but something else changed the
+	// screens since; Undo must bring back what they showed.
+	setter := &stateSetter{desktops: []string{"", ""}}
+	if code, out := runNotify(t, setter, address, dir, filepath.Join(dir, "dawn.png")); code != 0 {
+		t.Fatalf("set failed: %s", out)
+	}
+	setter.desktops = []string{"/tmp/left.png", "/tmp/right.png"}
+	setter.lockscreen = "/tmp/lock.png"
+
+	server.answer(actionUndo, nil)
+	code, out := runNotify(t, setter, address, dir, "--notify", filepath.Join(dir, "dusk.png"))
+	if code != 0 || !strings.Contains(out, "Restored previous wallpaper") {
+		t.Fatalf("unexpected undo result %d: %s", code, out)
+	}
+	if setter.desktops[0] != "/tmp/left.png" || setter.desktops[1] != "/tmp/right.png" || setter.lockscreen != "/tmp/lock.png" {
+		t.Fatalf("expected the shown wallpapers back, got %q and %q", setter.desktops, setter.lockscreen)
+	}
+}
+
+func TestNotifyReportsFailedUndo(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(dir, "dusk.png"), 9, 9)
+	server, address := startNotificationServer(t)
+
+	setter := &stateSetter{desktops: []string{"/tmp/old.png"}, restoreErr: errors.New("dbus")}
+	server.answer(actionUndo, nil)
+	code, out := runNotify(t, setter, address, dir, "--notify", filepath.Join(dir, "dusk.png"))
+	if code != 0 || !strings.Contains(out, "Failed to undo: dbus") {
+		t.Fatalf("unexpected undo result %d: %s", code, out)
+	}
+}
+
+func TestDaemonNotifiesWithoutActions(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(dir, "a.png"), 8, 8)
+	writeTestPNG(t, filepath.Join(dir, "b.png"), 9, 9)
+	if code := Main(context.Background(), []string{"library", "rescan", "-d", dir}, Deps{}); code != 0 {
+		t.Fatalf("rescan failed with %d", code)
+	}
+	server, address := startNotificationServer(t)
+	server.answer(actionUndo, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	setter := &recordingSetter{}
+	ticks := 0
+	var out bytes.Buffer
+	code := Main(ctx, []string{"daemon", "-d", dir, "--notify"}, Deps{
+		Setter:     setter,
+		Out:        &out,
+		Err:        &out,
+		ConfigDir:  func() (string, error) { return t.TempDir(), nil },
+		SessionBus: func() (*dbus.Conn, error) { return dbus.Connect(address) },
+		After: func(time.Duration) <-chan time.Time {
+			if ticks++; ticks == 2 {
+				cancel()
+			}
+			ch := make(chan time.Time, 1)
+			ch <- time.Time{}
+			return ch
+		},
+	})
+	if code != 0 {
+		t.Fatalf("daemon failed: %s", out.String())
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.bodies) < 2 || server.actions != 0 {
+		t.Fatalf("expected notifications without actions, got %d with %d actions", len(server.bodies), server.actions)
+	}
+	if len(setter.paths) != len(server.bodies) {
+		t.Fatalf("expected only the daemon's sets, got %v", setter.paths)
+	}
+}
@@ -0,0 +1,181 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"wugo/internal/config"
+	"wugo/internal/imaging"
+	"wugo/internal/library"
+	"wugo/internal/palette"
+)
+
+func runPalette(_ context.Context, args []string, deps Deps) int {
+	fs := flag.NewFlagSet("wugo palette", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("d", "", "Save directory")
+	export := fs.Bool("export", false, "Write the configured color scheme files")
+	output := fs.String("o", "", "Write the color scheme files to this directory")
+	var formats stringList
+	fs.Var(&formats, "format", "Export only this format (repeatable)")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return commandUsage(deps, "palette", err)
+	}
+	if len(positional) > 1 {
+		return commandUsage(deps, "palette", fmt.Errorf("expected at most one file"))
+	}
+	name := library.CurrentAlias
+	if len(positional) == 1 {
+		name = positional[0]
+	}
+
+	cfg, err := loadConfig(deps.ConfigDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to load config:", err)
+		return 1
+	}
+	if *output != "" {
+		cfg.Palette.Dir = *output
+	}
+	if len(formats) > 0 {
+		cfg.Palette.Formats = splitTags(formats...)
+	}
+	if _, err := paletteFormats(cfg.Palette); err != nil {
+		return commandUsage(deps, "palette", err)
+	}
+
+	path := name
+	if _, err := os.Stat(name); err != nil || name == library.CurrentAlias {
+		ix, code := openLibrary(deps, *dir)
+		if ix == nil {
+			return code
+		}
+		e, err := ix.Resolve(name)
+		if err != nil {
+			fmt.Fprintln(deps.Err, err)
+			return 1
+		}
+		path = ix.Path(*e)
+	}
+
+	p, err := extractPalette(path)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to extract palette:", err)
+		return 1
+	}
+
+	tw := tabwriter.NewWriter(deps.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "background\t%s\n", p.Background.Hex())
+	fmt.Fprintf(tw, "foreground\t%s\n", p.Foreground.Hex())
+	fmt.Fprintf(tw, "cursor\t%s\n", p.Cursor.Hex())
+	for i, c := range p.Colors {
+		fmt.Fprintf(tw, "color%d\t%s\n", i, c.Hex())
+	}
+	_ = tw.Flush()
+
+	if !*export && *output == "" {
+		return 0
+	}
+	exportDir, err := writePalette(deps, cfg.Palette, p, path)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to export palette:", err)
+		return 1
+	}
+	fmt.Fprintln(deps.Out, "Exported palette to", exportDir)
+	return 0
+}
+
+// autoPalette exports the palette of a wallpaper that was just set.
+// Failures are reported but do not fail the set.
+func autoPalette(deps Deps, cfg config.Palette, path string) {
+	p, err := extractPalette(path)
+	if err == nil {
+		_, err = writePalette(deps, cfg, p, path)
+	}
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to export palette:", err)
+	}
+}
+
+func extractPalette(path string) (palette.Palette, error) {
+	img, _, err := imaging.Decode(path)
+	if err != nil {
+		return palette.Palette{}, err
+	}
+	return palette.Extract(img), nil
+}
+
+// writePalette writes p in the configured formats and returns the
+// directory it wrote to.
+func writePalette(deps Deps, cfg config.Palette, p palette.Palette, wallpaper string) (string, error) {
+	formats, err := paletteFormats(cfg)
+	if err != nil {
+		return "", err
+	}
+	dir := expandHome(cfg.Dir, deps.HomeDir)
+	if dir == "" {
+		cache, err := deps.CacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(cache, "wugo", "palette")
+	}
+	if err := deps.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	for _, f := range formats {
+		var buf bytes.Buffer
+		if err := f.Write(&buf, p, wallpaper); err != nil {
+			return "", err
+		}
+		if err := writeFileAtomic(filepath.Join(dir, f.File), buf.Bytes()); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+func paletteFormats(cfg config.Palette) ([]palette.Format, error) {
+	if len(cfg.Formats) == 0 {
+		return palette.Formats, nil
+	}
+	formats := make([]palette.Format, 0, len(cfg.Formats))
+	for _, name := range cfg.Formats {
+		f, err := palette.LookupFormat(name)
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, f)
+	}
+	return formats, nil
+}
+
+// writeFileAtomic replaces path with data, so programs watching the file
+// never read it half-written.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
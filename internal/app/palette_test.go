@@ -0,0 +1,77 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPaletteExport(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "night.png")
+	writeTestPNG(t, image, 16, 16)
+	outDir := filepath.Join(t.TempDir(), "theme")
+
+	var out bytes.Buffer
+	code := Main(context.Background(), []string{"palette", image, "-o", outDir, "--format", "kitty,foot"}, Deps{
+		Out:       &out,
+		Err:       &out,
+		ConfigDir: func() (string, error) { return t.TempDir(), nil },
+	})
+	if code != 0 {
+		t.Fatalf("palette failed: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "color15") || !strings.Contains(out.String(), "Exported palette to "+outDir) {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+	entries, err := os.ReadDir(outDir)
+	if err != nil || len(entries) != 2 || entries[0].Name() != "foot.ini" || entries[1].Name() != "kitty.conf" {
+		t.Fatalf("expected only the requested formats, got %v (%v)", entries, err)
+	}
+
+	code = Main(context.Background(), []string{"palette", image, "--format", "vim"}, Deps{
+		Out:       &out,
+		Err:       &out,
+		ConfigDir: func() (string, error) { return t.TempDir(), nil },
+	})
+	if code != 2 {
+		t.Fatalf("expected unknown format to be a usage error, got %d", code)
+	}
+}
+
+func TestPaletteAfterSet(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "night.png")
+	writeTestPNG(t, image, 16, 16)
+
+	configDir := writeConfig(t, `{"palette": {"auto": true}}`)
+	cacheDir := t.TempDir()
+
+	var out bytes.Buffer
+	code := Main(context.Background(), []string{"-nm", "-d", dir, image}, Deps{
+		Setter:    &fakeSetter{},
+		Out:       &out,
+		Err:       &out,
+		ConfigDir: func() (string, error) { return configDir, nil },
+		CacheDir:  func() (string, error) { return cacheDir, nil },
+	})
+	if code != 0 {
+		t.Fatalf("set failed: %s", out.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(cacheDir, "wugo", "palette", "colors.json"))
+	if err != nil {
+		t.Fatalf("expected colors.json: %v", err)
+	}
+	var doc struct{ Wallpaper string }
+	if err := json.Unmarshal(data, &doc); err != nil || doc.Wallpaper != image {
+		t.Fatalf("unexpected colors.json (%v):\n%s", err, data)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "wugo", "palette", "Wugo.colors")); err != nil {
+		t.Fatalf("expected all formats by default: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"wugo/internal/config"
+	"wugo/internal/library"
+)
+
+func runPrune(_ context.Context, args []string, deps Deps) int {
+	fs := flag.NewFlagSet("wugo prune", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("d", "", "Save directory")
+	keepLast := fs.Int("keep-last", 0, "Keep only the N most recently added or set images")
+	maxSize := fs.String("max-size", "", "Remove the oldest images until the library fits, e.g. 5G")
+	olderThan := fs.String("older-than", "", "Remove images not added or set within this age, e.g. 90d")
+	dryRun := fs.Bool("dry-run", false, "Only report what would be removed")
+	if err := fs.Parse(args); err != nil {
+		return commandUsage(deps, "prune", err)
+	}
+
+	cfg, err := loadConfig(deps.ConfigDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to load config:", err)
+		return 1
+	}
+
+	policy, err := prunePolicy(cfg.Library.Prune, *keepLast, *maxSize, *olderThan)
+	if err != nil {
+		return commandUsage(deps, "prune", err)
+	}
+	if policy.IsZero() {
+		return commandUsage(deps, "prune", fmt.Errorf("no retention policy: use --keep-last, --max-size or --older-than, or set library.prune in the config"))
+	}
+
+	saveDir, err := resolveSaveDir(*dir, deps.HomeDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to resolve save directory:", err)
+		return 1
+	}
+	return prune(deps, saveDir, policy, *dryRun)
+}
+
+// prunePolicy merges the configured retention policy with command-line
+// values, which win when set.
+func prunePolicy(cfg config.Prune, keepLast int, maxSize, olderThan string) (library.Policy, error) {
+	policy := library.Policy{KeepLast: cfg.KeepLast}
+	if keepLast > 0 {
+		policy.KeepLast = keepLast
+	}
+
+	if size := cmp.Or(maxSize, cfg.MaxSize); size != "" {
+		n, err := library.ParseSize(size)
+		if err != nil {
+			return library.Policy{}, err
+		}
+		policy.MaxSize = n
+	}
+
+	if age := cmp.Or(olderThan, cfg.OlderThan); age != "" {
+		d, err := library.ParseAge(age)
+		if err != nil {
+			return library.Policy{}, err
+		}
+		policy.OlderThan = d
+	}
+
+	return policy, nil
+}
+
+// prune removes the images policy selects from the library of saveDir and
+// returns the exit code.
+func prune(deps Deps, saveDir string, policy library.Policy, dryRun bool) int {
+	ix, err := library.Lock(saveDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to open library:", err)
+		return 1
+	}
+	defer ix.Unlock()
+
+	var freed int64
+	removed, failed := 0, 0
+	for _, e := range ix.Prune(policy, deps.Now()) {
+		if dryRun {
+			fmt.Fprintf(deps.Out, "remove  %s (%s, last used %s)\n", e.File, humanSize(e.Size), lastUsed(e))
+		} else {
+			if err := ix.Delete(e); err != nil {
+				fmt.Fprintln(deps.Err, "Failed to remove", e.File+":", err)
+				failed++
+				continue
+			}
+			fmt.Fprintf(deps.Out, "removed %s (%s)\n", e.File, humanSize(e.Size))
+		}
+		removed++
+		freed += e.Size
+	}
+
+	if dryRun {
+		fmt.Fprintf(deps.Out, "Would remove %d images, freeing %s\n", removed, humanSize(freed))
+		return 0
+	}
+	if err := ix.Save(); err != nil {
+		fmt.Fprintln(deps.Err, "Failed to save library:", err)
+		return 1
+	}
+	fmt.Fprintf(deps.Out, "Removed %d images, freed %s\n", removed, humanSize(freed))
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func lastUsed(e library.Entry) string {
+	t := e.Added
+	if e.LastSet.After(t) {
+		t = e.LastSet
+	}
+	return t.Local().Format(timeLayout)
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	configDir := writeConfig(t, `{"library": {"prune": {"older_than": "30d"}}}`)
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	run := func(at time.Time, args ...string) (int, string) {
+		var out bytes.Buffer
+		code := Main(context.Background(), args, Deps{
+			Setter:    &fakeSetter{},
+			Out:       &out,
+			Err:       &out,
+			ConfigDir: func() (string, error) { return configDir, nil },
+			Now:       func() time.Time { return at },
+		})
+		return code, out.String()
+	}
+
+	for i, name := range []string{"a.png", "b.png", "c.png"} {
+		writeTestPNG(t, filepath.Join(dir, name), 8+i, 8)
+	}
+	if code, out := run(now.AddDate(0, -2, 0), "library", "rescan", "-d", dir); code != 0 {
+		t.Fatalf("rescan failed: %s", out)
+	}
+	if code, out := run(now, "fav", "a.png", "-d", dir); code != 0 {
+		t.Fatalf("fav failed: %s", out)
+	}
+	if code, out := run(now, "random", "-d", dir, "--fav"); code != 0 {
+		t.Fatalf("random failed: %s", out)
+	}
+
+	code, out := run(now, "prune", "-d", dir, "--dry-run")
+	if code != 0 || !strings.Contains(out, "remove  b.png") || !strings.Contains(out, "remove  c.png") ||
+		strings.Contains(out, "a.png") {
+		t.Fatalf("unexpected dry run %d: %s", code, out)
+	}
+
+	code, out = run(now, "prune", "-d", dir, "--older-than", "90d")
+	if code != 0 || !strings.Contains(out, "Removed 0 images") {
+		t.Fatalf("expected flag to override config %d: %s", code, out)
+	}
+
+	code, out = run(now, "prune", "-d", dir)
+	if code != 0 || !strings.Contains(out, "Removed 2 images") {
+		t.Fatalf("unexpected prune %d: %s", code, out)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 || entries[0].Name() != ".wugo" || entries[1].Name() != "a.png" {
+		t.Fatalf("unexpected files left: %v", entries)
+	}
+
+	if code, _ := run(now, "prune", "-d", dir, "--max-size", "huge"); code != 2 {
+		t.Fatalf("expected exit code 2 for bad size, got %d", code)
+	}
+}
+
+func TestPruneWithoutPolicy(t *testing.T) {
+	var out bytes.Buffer
+	code := Main(context.Background(), []string{"prune", "-d", t.TempDir()}, Deps{
+		Out:       &out,
+		Err:       &out,
+		ConfigDir: func() (string, error) { return t.TempDir(), nil },
+	})
+	if code != 2 || !strings.Contains(out.String(), "no retention policy") {
+		t.Fatalf("unexpected result %d: %s", code, out.String())
+	}
+}
+
+func TestDaemonPrunes(t *testing.T) {
+	dir := t.TempDir()
+	configDir := writeConfig(t, `{"library": {"prune": {"keep_last": 1, "every": "1h"}}}`)
+	writeTestPNG(t, filepath.Join(dir, "a.png"), 8, 8)
+	writeTestPNG(t, filepath.Join(dir, "b.png"), 9, 8)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var out bytes.Buffer
+	deps := Deps{
+		Setter:    &fakeSetter{},
+		Out:       &out,
+		Err:       &out,
+		ConfigDir: func() (string, error) { return configDir, nil },
+		After: func(time.Duration) <-chan time.Time {
+			cancel()
+			return make(chan time.Time)
+		},
+	}
+	if code := Main(ctx, []string{"library", "rescan", "-d", dir}, deps); code != 0 {
+		t.Fatalf("rescan failed: %s", out.String())
+	}
+	if code := Main(ctx, []string{"daemon", "-d", dir}, deps); code != 0 {
+		t.Fatalf("daemon failed: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "Removed 1 images") {
+		t.Fatalf("expected daemon to prune, got %s", out.String())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatalf("expected only the current wallpaper left, got %v", entries)
+	}
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"wugo/internal/config"
+	"wugo/internal/library"
+	"wugo/internal/schedule"
+)
+
+const defaultInterval = 30 * time.Minute
+
+func runRandom(ctx context.Context, args []string, deps Deps) int {
+	fs := flag.NewFlagSet("wugo random", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("d", "", "Save directory")
+	filters := addFilterFlags(fs)
+	so := addSetFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return commandUsage(deps, "random", err)
+	}
+	q, err := filters.query()
+	if err != nil {
+		return commandUsage(deps, "random", err)
+	}
+
+	cfg, err := loadConfig(deps.ConfigDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to load config:", err)
+		return 1
+	}
+	saveDir, err := resolveSaveDir(*dir, deps.HomeDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to resolve save directory:", err)
+		return 1
+	}
+	return setRandom(ctx, deps, cfg, saveDir, q, *so)
+}
+
+// runDaemon sets a random library image matching the filters at startup
+// and then every interval until ctx is cancelled. When a schedule is
+// configured it follows the schedule instead, and with --dynamic it plays a
+// dynamic wallpaper. With a prune interval it also
+// applies the configured retention policy that often.
+func runDaemon(ctx context.Context, args []string, deps Deps) int {
+	fs := flag.NewFlagSet("wugo daemon", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("d", "", "Save directory")
+	interval := fs.Duration("interval", defaultInterval, "Time between wallpaper changes")
+	pruneEvery := fs.String("prune-every", "", "Apply the configured retention policy this often, e.g. 24h")
+	bundle := fs.String("dynamic", "", "Play this dynamic wallpaper bundle")
+	filters := addFilterFlags(fs)
+	so := addSetFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return commandUsage(deps, "daemon", err)
+	}
+	so.noActions = true
+	if *interval <= 0 {
+		return commandUsage(deps, "daemon", fmt.Errorf("interval must be positive"))
+	}
+	q, err := filters.query()
+	if err != nil {
+		return commandUsage(deps, "daemon", err)
+	}
+
+	cfg, err := loadConfig(deps.ConfigDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to load config:", err)
+		return 1
+	}
+	var every time.Duration
+	if value := cmp.Or(*pruneEvery, cfg.Library.Prune.Every); value != "" {
+		if every, err = library.ParseAge(value); err != nil {
+			return commandUsage(deps, "daemon", err)
+		}
+	}
+	policy, err := prunePolicy(cfg.Library.Prune, 0, "", "")
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to load config:", err)
+		return 1
+	}
+	if every > 0 && policy.IsZero() {
+		return commandUsage(deps, "daemon", fmt.Errorf("--prune-every needs a retention policy in library.prune"))
+	}
+
+	saveDir, err := resolveSaveDir(*dir, deps.HomeDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to resolve save directory:", err)
+		return 1
+	}
+
+	var player *dynamicPlayer
+	var sched *scheduler
+	if *bundle != "" {
+		var code int
+		if player, code = newDynamicPlayer(deps, cfg, saveDir, *bundle); player == nil {
+			return code
+		}
+	} else if len(cfg.Schedule) > 0 {
+		s, err := schedule.New(cfg.Schedule, cfg.Location)
+		if err != nil {
+			fmt.Fprintln(deps.Err, "Failed to load config:", err)
+			return 1
+		}
+		sched = &scheduler{sched: s}
+	}
+
+	var lastPrune time.Time
+	for {
+		wait := *interval
+		if player != nil {
+			wait = player.step(ctx, deps, cfg, saveDir, *so)
+		} else if sched != nil {
+			wait = sched.step(ctx, deps, cfg, saveDir, q, *so)
+		} else {
+			setRandom(ctx, deps, cfg, saveDir, q, *so)
+		}
+		if now := deps.Now(); every > 0 && (lastPrune.IsZero() || now.Sub(lastPrune) >= every) {
+			prune(deps, saveDir, policy, false)
+			lastPrune = now
+		}
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-deps.After(wait):
+		}
+	}
+}
+
+// setRandom sets a random library image matching q and returns the exit
+// code.
+func setRandom(ctx context.Context, deps Deps, cfg config.Config, saveDir string, q library.Query, so setOptions) int {
+	ix, err := library.Open(saveDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to open library:", err)
+		return 1
+	}
+
+	e, err := ix.Random(q, nil)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to pick image:", err)
+		return 1
+	}
+
+	return setWallpaper(ctx, deps, cfg, saveDir, ix.Path(*e), so)
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingSetter struct {
+	fakeSetter
+	paths []string
+}
+
+func (r *recordingSetter) SetDesktop(ctx context.Context, path string) error {
+	r.paths = append(r.paths, path)
+	return r.fakeSetter.SetDesktop(ctx, path)
+}
+
+func TestTagFavAndRandom(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(dir, "night.png"), 32, 32)
+	writeTestPNG(t, filepath.Join(dir, "noon.png"), 16, 16)
+
+	setter := &recordingSetter{}
+	run := func(args ...string) (int, string) {
+		var out bytes.Buffer
+		code := Main(context.Background(), args, Deps{Setter: setter, Out: &out, Err: &out})
+		return code, out.String()
+	}
+
+	if code, out := run("fav", "-d", dir); code != 1 || !strings.Contains(out, "no wallpaper set yet") {
+		t.Fatalf("expected fav without current to fail, got %d: %s", code, out)
+	}
+	if code, out := run("library", "rescan", "-d", dir); code != 0 {
+		t.Fatalf("rescan failed: %s", out)
+	}
+	if code, out := run("tag", "add", "night.png", "dark", "Space", "-d", dir); code != 0 || !strings.Contains(out, "dark, space") {
+		t.Fatalf("unexpected tag add result %d: %s", code, out)
+	}
+
+	code, out := run("random", "-d", dir, "--tag", "dark")
+	if code != 0 || len(setter.paths) != 1 || filepath.Base(setter.paths[0]) != "night.png" {
+		t.Fatalf("unexpected random result %d: %s %v", code, out, setter.paths)
+	}
+
+	if code, out := run("fav", "-d", dir); code != 0 || !strings.Contains(out, "night.png") {
+		t.Fatalf("unexpected fav result %d: %s", code, out)
+	}
+	if code, out := run("tag", "rm", "current", "space", "-d", dir); code != 0 || !strings.Contains(out, "night.png: dark") {
+		t.Fatalf("unexpected tag rm result %d: %s", code, out)
+	}
+
+	code, out = run("list", "-d", dir, "--tag", "dark", "--fav")
+	if code != 0 || !strings.Contains(out, "night.png") || strings.Contains(out, "noon.png") {
+		t.Fatalf("unexpected filtered list %d: %s", code, out)
+	}
+	if code, out := run("tag", "ls", "-d", dir); code != 0 || out != "dark\t1\n" {
+		t.Fatalf("unexpected tag ls %d: %q", code, out)
+	}
+
+	if code, _ := run("random", "-d", dir, "--tag", "forest"); code != 1 {
+		t.Fatalf("expected exit code 1 without matches, got %d", code)
+	}
+	if code, _ := run("tag", "paint", "-d", dir); code != 2 {
+		t.Fatalf("expected exit code 2 for unknown subcommand, got %d", code)
+	}
+}
+
+func TestDaemonRotatesUntilCancelled(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(dir, "a.png"), 8, 8)
+	writeTestPNG(t, filepath.Join(dir, "b.png"), 8, 8)
+	if code := Main(context.Background(), []string{"library", "rescan", "-d", dir}, Deps{}); code != 0 {
+		t.Fatalf("rescan failed with %d", code)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	setter := &recordingSetter{}
+	var intervals []time.Duration
+	deps := Deps{
+		Setter:    setter,
+		ConfigDir: func() (string, error) { return t.TempDir(), nil },
+		After: func(d time.Duration) <-chan time.Time {
+			intervals = append(intervals, d)
+			if len(intervals) == 3 {
+				cancel()
+			}
+			ch := make(chan time.Time, 1)
+			ch <- time.Time{}
+			return ch
+		},
+	}
+
+	code := Main(ctx, []string{"daemon", "-d", dir, "--interval", "10m"}, deps)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if len(setter.paths) < 3 {
+		t.Fatalf("expected a wallpaper per tick, got %v", setter.paths)
+	}
+	for i := 1; i < len(setter.paths); i++ {
+		if setter.paths[i] == setter.paths[i-1] {
+			t.Fatalf("expected the current wallpaper not to repeat: %v", setter.paths)
+		}
+	}
+	if intervals[0] != 10*time.Minute {
+		t.Fatalf("unexpected interval: %v", intervals[0])
+	}
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"wugo/internal/config"
+	"wugo/internal/image"
+	"wugo/internal/library"
+	"wugo/internal/schedule"
+)
+
+// scheduleTick caps how long the daemon sleeps while following a schedule.
+// Timers do not advance during suspend, so waking regularly to look at the
+// wall clock is what catches a resume past a boundary.
+const scheduleTick = time.Minute
+
+const previewWindow = 24 * time.Hour
+
+func runSchedule(_ context.Context, args []string, deps Deps) int {
+	if len(args) == 0 || args[0] != "preview" {
+		return commandUsage(deps, "schedule", fmt.Errorf("expected subcommand: preview"))
+	}
+
+	cfg, err := loadConfig(deps.ConfigDir)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to load config:", err)
+		return 1
+	}
+	if len(cfg.Schedule) == 0 {
+		fmt.Fprintln(deps.Err, "No schedule configured")
+		return 1
+	}
+	sched, err := schedule.New(cfg.Schedule, cfg.Location)
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to load config:", err)
+		return 1
+	}
+
+	tw := tabwriter.NewWriter(deps.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FROM\tTO\tENTRY\tSHOWS")
+	for _, p := range sched.Preview(deps.Now(), previewWindow) {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			p.Start.Format("Mon 15:04"), p.End.Format("Mon 15:04"), entryName(p), schedule.Describe(p.Entry))
+	}
+	_ = tw.Flush()
+	return 0
+}
+
+func entryName(p schedule.Period) string {
+	return cmp.Or(p.Entry.Name, fmt.Sprintf("#%d", p.Index+1))
+}
+
+// scheduler follows a schedule in the daemon, applying each entry once when
+// it starts.
+type scheduler struct {
+	sched    *schedule.Schedule
+	applied  schedule.Period
+	started  bool
+	lastWake time.Time
+	lastWait time.Duration
+}
+
+// step applies the entry active now if it has not been applied yet and
+// returns how long to sleep.
+func (s *scheduler) step(ctx context.Context, deps Deps, cfg config.Config, saveDir string, q library.Query, so setOptions) time.Duration {
+	now := deps.Now()
+	if !s.lastWake.IsZero() {
+		if gap := now.Sub(s.lastWake) - s.lastWait; gap > scheduleTick {
+			fmt.Fprintf(deps.Err, "Clock jumped by %s, re-evaluating schedule\n", gap.Round(time.Second))
+		}
+	}
+
+	if p, ok := s.sched.At(now); ok && !(s.started && p.Same(s.applied)) {
+		fmt.Fprintf(deps.Out, "Schedule: %s (%s)\n", entryName(p), schedule.Describe(p.Entry))
+		if setEntry(ctx, deps, cfg, saveDir, p.Entry, q, so) == 0 {
+			s.applied, s.started = p, true
+		}
+	}
+
+	wait := scheduleTick
+	if next := s.sched.Next(now); !next.IsZero() && next.Sub(now) < wait {
+		wait = next.Sub(now)
+	}
+	s.lastWake, s.lastWait = now, wait
+	return wait
+}
+
+// setEntry shows what a schedule entry names and returns the exit code. Tag
+// entries add to the daemon's filters.
+func setEntry(ctx context.Context, deps Deps, cfg config.Config, saveDir string, e config.ScheduleEntry, q library.Query, so setOptions) int {
+	if e.Tag != "" {
+		q.Tags = append(slices.Clone(q.Tags), splitTags(e.Tag)...)
+		return setRandom(ctx, deps, cfg, saveDir, q, so)
+	}
+
+	var path string
+	var err error
+	if e.Image != "" {
+		path, err = entryImage(deps, saveDir, e.Image)
+	} else {
+		path, err = randomImage(expandHome(e.Folder, deps.HomeDir))
+	}
+	if err != nil {
+		fmt.Fprintln(deps.Err, "Failed to pick image:", err)
+		return 1
+	}
+	return setWallpaper(ctx, deps, cfg, saveDir, path, so)
+}
+
+// entryImage resolves an absolute path as is and anything else through the
+// library of saveDir.
+func entryImage(deps Deps, saveDir, name string) (string, error) {
+	name = expandHome(name, deps.HomeDir)
+	if filepath.IsAbs(name) {
+		return name, nil
+	}
+	ix, err := library.Open(saveDir)
+	if err != nil {
+		return "", err
+	}
+	e, err := ix.Resolve(name)
+	if err != nil {
+		return "", err
+	}
+	return ix.Path(*e), nil
+}
+
+// randomImage picks a random image file directly inside dir.
+func randomImage(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var images []string
+	for _, e := range entries {
+		if !e.IsDir() && image.IsImageFile(e.Name()) {
+			images = append(images, filepath.Join(dir, e.Name()))
+		}
+	}
+	if len(images) == 0 {
+		return "", fmt.Errorf("no images in %s", dir)
+	}
+	return images[rand.IntN(len(images))], nil
+}
+
+func expandHome(path string, homeDir func() (string, error)) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := homeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchedulePreview(t *testing.T) {
+	configDir := writeConfig(t, `{"schedule": [
+		{"name": "day", "time": "08:00-20:00", "image": "day.png"},
+		{"name": "night", "time": "20:00-08:00", "tag": "dark"}
+	]}`)
+
+	var out bytes.Buffer
+	code := Main(context.Background(), []string{"schedule", "preview"}, Deps{
+		Out:       &out,
+		Err:       &out,
+		ConfigDir: func() (string, error) { return configDir, nil },
+		Now:       func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local) },
+	})
+	if code != 0 {
+		t.Fatalf("preview failed: %s", out.String())
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 ||
+		!strings.Contains(lines[1], "Fri 12:00  Fri 20:00  day    image day.png") ||
+		!strings.Contains(lines[2], "Fri 20:00  Sat 08:00  night  tag dark") ||
+		!strings.Contains(lines[3], "Sat 08:00  Sat 12:00  day") {
+		t.Fatalf("unexpected preview:\n%s", out.String())
+	}
+}
+
+func TestDaemonFollowsSchedule(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(dir, "day.png"), 8, 8)
+	writeTestPNG(t, filepath.Join(dir, "night.png"), 9, 9)
+	configDir := writeConfig(t, `{"schedule": [
+		{"name": "day", "time": "08:00-20:00", "image": "day.png"},
+		{"name": "night", "time": "20:00-08:00", "image": "night.png"}
+	]}`)
+	if code := Main(context.Background(), []string{"library", "rescan", "-d", dir}, Deps{}); code != 0 {
+		t.Fatalf("rescan failed with %d", code)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := time.Date(2026, 10, 16, 19, 58, 0, 0, time.Local)
+	setter := &recordingSetter{}
+	var waits []time.Duration
+	var out bytes.Buffer
+	deps := Deps{
+		Setter:    setter,
+		Out:       &out,
+		Err:       &out,
+		ConfigDir: func() (string, error) { return configDir, nil },
+		Now:       func() time.Time { return clock },
+		After: func(d time.Duration) <-chan time.Time {
+			waits = append(waits, d)
+			clock = clock.Add(d)
+			switch len(waits) {
+			case 3:
+				// Suspended overnight: the timer fires long after it was due.
+				clock = clock.Add(13 * time.Hour)
+			case 4:
+				cancel()
+				return make(chan time.Time)
+			}
+			ch := make(chan time.Time, 1)
+			ch <- clock
+			return ch
+		},
+	}
+
+	if code := Main(ctx, []string{"daemon", "-d", dir}, deps); code != 0 {
+		t.Fatalf("daemon failed: %s", out.String())
+	}
+
+	var names []string
+	for _, p := range setter.paths {
+		names = append(names, filepath.Base(p))
+	}
+	if strings.Join(names, ",") != "day.png,night.png,day.png" {
+		t.Fatalf("unexpected wallpapers: %v\n%s", names, out.String())
+	}
+	if waits[0] != time.Minute || waits[1] != time.Minute {
+		t.Fatalf("unexpected waits: %v", waits)
+	}
+	if !strings.Contains(out.String(), "Clock jumped by 13h0m0s") {
+		t.Fatalf("expected clock jump to be noticed: %s", out.String())
+	}
+}
+
+func TestSchedulePreviewSun(t *testing.T) {
+	configDir := writeConfig(t, `{
+		"location": {"latitude": 48.8566, "longitude": 2.3522},
+		"schedule": [{"name": "light", "sun": "day", "tag": "light"}, {"name": "dark", "sun": "night", "tag": "dark"}]
+	}`)
+
+	var out bytes.Buffer
+	code := Main(context.Background(), []string{"schedule", "preview"}, Deps{
+		Out:       &out,
+		Err:       &out,
+		ConfigDir: func() (string, error) { return configDir, nil },
+		Now:       func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) },
+	})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if code != 0 || len(lines) != 4 ||
+		!strings.HasPrefix(lines[1], "Fri 12:00  Fri 16:59  light") ||
+		!strings.HasPrefix(lines[2], "Fri 16:59  Sat 06:14  dark") {
+		t.Fatalf("unexpected preview %d:\n%s", code, out.String())
+	}
+}
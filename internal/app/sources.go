@@ -0,0 +1,58 @@
+package app
+
+import (
+	"cmp"
+
+	"wugo/internal/config"
+	"wugo/internal/httpclient"
+	"wugo/internal/image"
+	"wugo/internal/source"
+)
+
+// remote is how sources that do not go through proc's HTTP client reach
+// the network.
+type remote struct {
+	dial    httpclient.DialFunc
+	offline bool
+}
+
+// registerSources adds the remote providers to proc, with flags taking
+// precedence over config defaults.
+func registerSources(proc *image.Processor, opts Options, cfg config.Sources, net remote) {
+	proc.Register(&source.Wallhaven{
+		Fetcher: proc,
+		APIKey:  cfg.Wallhaven.APIKey,
+		Purity:  cmp.Or(opts.Purity, cfg.Wallhaven.Purity),
+		Ratio:   cmp.Or(opts.Ratio, cfg.Wallhaven.Ratio),
+		AtLeast: cmp.Or(opts.AtLeast, cfg.Wallhaven.AtLeast),
+	})
+
+	proc.Register(&source.Bing{Fetcher: proc, Market: cfg.Bing.Market})
+	proc.Register(&source.APOD{Fetcher: proc, APIKey: cfg.APOD.APIKey})
+	proc.Register(&source.Reddit{
+		Fetcher:       proc,
+		MinResolution: cfg.Reddit.MinResolution,
+		AllowNSFW:     cfg.Reddit.AllowNSFW,
+	})
+	proc.Register(&source.Feed{Fetcher: proc})
+	proc.Register(&source.SFTP{Storer: proc, Dial: net.dial, Offline: net.offline})
+	proc.Register(&source.S3{
+		Client:   proc,
+		Storer:   proc,
+		Endpoint: cfg.S3.Endpoint,
+		Region:   cfg.S3.Region,
+		Profile:  cfg.S3.Profile,
+	})
+
+	for name, api := range cfg.API {
+		proc.Register(&source.API{
+			Fetcher:     proc,
+			Name:        name,
+			URL:         api.URL,
+			Image:       api.Image,
+			Author:      api.Author,
+			Attribution: api.Attribution,
+			Title:       api.Title,
+		})
+	}
+}
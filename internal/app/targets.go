@@ -0,0 +1,167 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"wugo/internal/wallpaper"
+)
+
+// target is one place setWallpaper puts an image. capture is nil when the
+// backend cannot read the target back.
+type target struct {
+	name    string
+	set     func(context.Context, string) error
+	capture func(context.Context) (snapshot, error)
+}
+
+// snapshot is what a target showed before a set. restore is nil when there
+// is nothing to go back to, and image is set only when the target showed a
+// single image.
+type snapshot struct {
+	desc    string
+	image   string
+	restore func(context.Context) error
+}
+
+// targetsOf lists the targets of s in the order they are set.
+func targetsOf(s wallpaper.Setter) []target {
+	targets := []target{
+		{name: "desktop", set: s.SetDesktop},
+		{name: "lockscreen", set: s.SetLockscreen},
+	}
+	if g, ok := s.(wallpaper.Getter); ok {
+		targets[0].capture = func(ctx context.Context) (snapshot, error) {
+			desktops, err := g.GetDesktop(ctx)
+			if err != nil {
+				return snapshot{}, err
+			}
+			return desktopSnapshot(desktops, g), nil
+		}
+		targets[1].capture = func(ctx context.Context) (snapshot, error) {
+			path, err := g.GetLockscreen(ctx)
+			if err != nil || path == "" {
+				return snapshot{}, err
+			}
+			restore := func(ctx context.Context) error { return s.SetLockscreen(ctx, path) }
+			return snapshot{desc: path, image: path, restore: restore}, nil
+		}
+	}
+	return targets
+}
+
+// desktopSnapshot restores every desktop in desktops to its own image.
+func desktopSnapshot(desktops []wallpaper.Desktop, g wallpaper.Getter) snapshot {
+	if len(desktops) == 0 {
+		return snapshot{}
+	}
+	var images []string
+	seen := map[string]bool{}
+	for _, d := range desktops {
+		if d.Image != "" && !seen[d.Image] {
+			seen[d.Image] = true
+			images = append(images, d.Image)
+		}
+	}
+	snap := snapshot{
+		desc:    strings.Join(images, ", "),
+		restore: func(ctx context.Context) error { return g.RestoreDesktop(ctx, desktops) },
+	}
+	if snap.desc == "" {
+		snap.desc = "previous settings"
+	}
+	if len(images) == 1 {
+		snap.image = images[0]
+	}
+	return snap
+}
+
+// restoreSnapshots puts back every snapshot that can be restored.
+func restoreSnapshots(ctx context.Context, snaps []snapshot) error {
+	var errs []error
+	for _, s := range snaps {
+		if s.restore == nil {
+			continue
+		}
+		if err := s.restore(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restorable reports whether any of snaps can be restored.
+func restorable(snaps []snapshot) bool {
+	for _, s := range snaps {
+		if s.restore != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTargets sets path on every target and returns the failures as
+// "target: error", along with what the targets showed before when the
+// backend can read them back. Unless noRollback is set, it then stops at
+// the first failure and restores the targets it already changed. The
+// outcome of every target is printed, whether or not one failed.
+func applyTargets(ctx context.Context, deps Deps, path string, noRollback bool) ([]snapshot, []string) {
+	targets := targetsOf(deps.Setter)
+	var previous []snapshot
+	if targets[0].capture != nil {
+		previous = make([]snapshot, len(targets))
+		for i, t := range targets {
+			snap, err := t.capture(ctx)
+			if err != nil {
+				if !noRollback {
+					fmt.Fprintf(deps.Err, "Failed to read current %s wallpaper (use --no-rollback to set anyway): %v\n", t.name, err)
+					return nil, []string{t.name + ": " + err.Error()}
+				}
+				previous = nil
+				break
+			}
+			previous[i] = snap
+		}
+	}
+	rollback := !noRollback && previous != nil
+
+	var errs []string
+	changed := make([]bool, len(targets))
+	tried := len(targets)
+	for i, t := range targets {
+		if err := t.set(ctx, path); err != nil {
+			fmt.Fprintf(deps.Err, "Failed to set %s wallpaper: %v\n", t.name, err)
+			errs = append(errs, t.name+": "+err.Error())
+			if rollback {
+				tried = i + 1
+				break
+			}
+			continue
+		}
+		changed[i] = true
+	}
+
+	// The changes are undone even if ctx was cancelled meanwhile.
+	restoreCtx := context.WithoutCancel(ctx)
+	for i, t := range targets {
+		switch {
+		case i >= tried:
+			fmt.Fprintf(deps.Out, "Left %s wallpaper unchanged\n", t.name)
+		case !changed[i]:
+			// Reported when it failed.
+		case len(errs) == 0 || !rollback:
+			fmt.Fprintf(deps.Out, "Set %s wallpaper: %s\n", t.name, path)
+		case previous[i].restore == nil:
+			fmt.Fprintf(deps.Err, "Cannot restore %s wallpaper: no previous image\n", t.name)
+		default:
+			if err := previous[i].restore(restoreCtx); err != nil {
+				fmt.Fprintf(deps.Err, "Failed to restore %s wallpaper: %v\n", t.name, err)
+				continue
+			}
+			fmt.Fprintf(deps.Out, "Restored %s wallpaper: %s\n", t.name, previous[i].desc)
+		}
+	}
+	return previous, errs
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+
+	"wugo/internal/wallpaper"
+)
+
+// stateSetter keeps what each target shows, so rollbacks can be checked.
+// desktops holds one image per screen; SetDesktop puts path on all of them.
+type stateSetter struct {
+	desktops                  []string
+	lockscreen                string
+	desktopErr, lockscreenErr error
+	restoreErr                error
+	lockscreenCalls           int
+}
+
+func (s *stateSetter) SetDesktop(_ context.Context, path string) error {
+	if s.desktopErr != nil {
+		return s.desktopErr
+	}
+	for i := range s.desktops {
+		s.desktops[i] = path
+	}
+	return nil
+}
+
+func (s *stateSetter) SetLockscreen(_ context.Context, path string) error {
+	s.lockscreenCalls++
+	if s.lockscreenErr != nil {
+		return s.lockscreenErr
+	}
+	s.lockscreen = path
+	return nil
+}
+
+func (s *stateSetter) GetDesktop(context.Context) ([]wallpaper.Desktop, error) {
+	var desktops []wallpaper.Desktop
+	for i, image := range s.desktops {
+		desktops = append(desktops, wallpaper.Desktop{ID: strconv.Itoa(i), Image: image})
+	}
+	return desktops, nil
+}
+
+func (s *stateSetter) RestoreDesktop(_ context.Context, desktops []wallpaper.Desktop) error {
+	if s.restoreErr != nil {
+		return s.restoreErr
+	}
+	for _, d := range desktops {
+		i, _ := strconv.Atoi(d.ID)
+		s.desktops[i] = d.Image
+	}
+	return nil
+}
+
+func (s *stateSetter) GetLockscreen(context.Context) (string, error) { return s.lockscreen, nil }
+
+func runWithSetter(t *testing.T, setter *stateSetter, args ...string) (int, string) {
+	t.Helper()
+	var out bytes.Buffer
+	code := Main(context.Background(), args, Deps{
+		Processor: &fakeProcessor{result: "/tmp/new.png"},
+		Setter:    setter,
+		Out:       &out,
+		Err:       &out,
+		MkdirAll:  func(string, fs.FileMode) error { return nil },
+		HomeDir:   func() (string, error) { return t.TempDir(), nil },
+		ConfigDir: func() (string, error) { return t.TempDir(), nil },
+	})
+	return code, out.String()
+}
+
+func TestSetRollsBackOnFailure(t *testing.T) {
+	setter := &stateSetter{desktops: []string{"/tmp/old.png"}, lockscreen: "/tmp/old-lock.png", lockscreenErr: errors.New("read-only")}
+
+	code, out := runWithSetter(t, setter, "/tmp/input.png")
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if setter.desktops[0] != "/tmp/old.png" || setter.lockscreen != "/tmp/old-lock.png" {
+		t.Fatalf("expected previous wallpapers, got %q and %q", setter.desktops, setter.lockscreen)
+	}
+	if !strings.Contains(out, "Failed to set lockscreen wallpaper: read-only") ||
+		!strings.Contains(out, "Restored desktop wallpaper: /tmp/old.png") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestSetRollbackRestoresEachDesktop(t *testing.T) {
+	setter := &stateSetter{desktops: []string{"/tmp/left.png", "/tmp/right.png"}, lockscreenErr: errors.New("read-only")}
+
+	code, out := runWithSetter(t, setter, "/tmp/input.png")
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if setter.desktops[0] != "/tmp/left.png" || setter.desktops[1] != "/tmp/right.png" {
+		t.Fatalf("expected each screen's wallpaper back, got %q", setter.desktops)
+	}
+	if !strings.Contains(out, "Restored desktop wallpaper: /tmp/left.png, /tmp/right.png") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestSetReportsEveryTarget(t *testing.T) {
+	setter := &stateSetter{desktops: []string{"/tmp/old.png"}}
+
+	code, out := runWithSetter(t, setter, "/tmp/input.png")
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, out)
+	}
+	for _, want := range []string{"Set desktop wallpaper: /tmp/new.png", "Set lockscreen wallpaper: /tmp/new.png"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in output: %s", want, out)
+		}
+	}
+}
+
+func TestSetStopsAtFirstFailure(t *testing.T) {
+	setter := &stateSetter{desktopErr: errors.New("dbus")}
+
+	code, out := runWithSetter(t, setter, "/tmp/input.png")
+	if code != 1 || setter.lockscreenCalls != 0 {
+		t.Fatalf("expected the lock screen untouched, got %d calls (exit %d)", setter.lockscreenCalls, code)
+	}
+	if !strings.Contains(out, "Left lockscreen wallpaper unchanged") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestSetNoRollbackKeepsPartialSuccess(t *testing.T) {
+	setter := &stateSetter{desktops: []string{"/tmp/old.png"}, lockscreenErr: errors.New("read-only")}
+
+	code, out := runWithSetter(t, setter, "--no-rollback", "/tmp/input.png")
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if setter.desktops[0] != "/tmp/new.png" {
+		t.Fatalf("expected the new desktop wallpaper kept, got %q", setter.desktops[0])
+	}
+	if !strings.Contains(out, "Set desktop wallpaper: /tmp/new.png") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
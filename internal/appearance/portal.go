@@ -0,0 +1,111 @@
+// Package appearance reads and watches the desktop's light/dark preference
+// through the XDG desktop portal.
+package appearance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	portalName    = "org.freedesktop.portal.Desktop"
+	portalPath    = dbus.ObjectPath("/org/freedesktop/portal/desktop")
+	settingsIface = "org.freedesktop.portal.Settings"
+	namespace     = "org.freedesktop.appearance"
+	key           = "color-scheme"
+)
+
+// Scheme is the portal's color-scheme setting.
+type Scheme uint32
+
+const (
+	NoPreference Scheme = iota
+	Dark
+	Light
+)
+
+func (s Scheme) String() string {
+	switch s {
+	case Dark:
+		return "dark"
+	case Light:
+		return "light"
+	}
+	return "no preference"
+}
+
+// Read returns the current color scheme.
+func Read(conn *dbus.Conn) (Scheme, error) {
+	obj := conn.Object(portalName, portalPath)
+	var v dbus.Variant
+	err := obj.Call(settingsIface+".ReadOne", 0, namespace, key).Store(&v)
+	if err != nil {
+		// Portals before version 2 only have Read, which nests the value in
+		// a second variant.
+		if obj.Call(settingsIface+".Read", 0, namespace, key).Store(&v) != nil {
+			return NoPreference, err
+		}
+	}
+	return schemeOf(v)
+}
+
+// Watch calls fn with each color scheme the portal announces until ctx is
+// cancelled.
+func Watch(ctx context.Context, conn *dbus.Conn, fn func(Scheme)) error {
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(portalPath),
+		dbus.WithMatchInterface(settingsIface),
+		dbus.WithMatchMember("SettingChanged"),
+		dbus.WithMatchArg(0, namespace),
+	}
+	if err := conn.AddMatchSignal(match...); err != nil {
+		return err
+	}
+	defer conn.RemoveMatchSignal(match...)
+
+	signals := make(chan *dbus.Signal, 8)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig, ok := <-signals:
+			if !ok {
+				return errors.New("D-Bus connection closed")
+			}
+			if sig.Name != settingsIface+".SettingChanged" || len(sig.Body) != 3 {
+				continue
+			}
+			if ns, _ := sig.Body[0].(string); ns != namespace {
+				continue
+			}
+			if k, _ := sig.Body[1].(string); k != key {
+				continue
+			}
+			v, _ := sig.Body[2].(dbus.Variant)
+			if s, err := schemeOf(v); err == nil {
+				fn(s)
+			}
+		}
+	}
+}
+
+func schemeOf(v dbus.Variant) (Scheme, error) {
+	for {
+		inner, ok := v.Value().(dbus.Variant)
+		if !ok {
+			break
+		}
+		v = inner
+	}
+	n, ok := v.Value().(uint32)
+	if !ok {
+		return NoPreference, fmt.Errorf("unexpected %s value: %s", key, v)
+	}
+	return Scheme(n), nil
+}
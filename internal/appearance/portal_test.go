@@ -0,0 +1,93 @@
+package appearance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"wugo/internal/dbustest"
+)
+
+type fakePortal struct {
+	scheme uint32
+}
+
+func (p *fakePortal) ReadOne(ns, k string) (dbus.Variant, *dbus.Error) {
+	if ns != namespace || k != key {
+		return dbus.Variant{}, dbus.NewError("org.freedesktop.portal.Error.NotFound", nil)
+	}
+	return dbus.MakeVariant(p.scheme), nil
+}
+
+func startPortal(t *testing.T, address string, scheme uint32) *dbus.Conn {
+	t.Helper()
+	conn := dbustest.Connect(t, address)
+	if err := conn.Export(&fakePortal{scheme: scheme}, portalPath, settingsIface); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if reply, err := conn.RequestName(portalName, dbus.NameFlagDoNotQueue); err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("request name: %v %v", reply, err)
+	}
+	return conn
+}
+
+func TestReadAndWatch(t *testing.T) {
+	address := dbustest.Start(t)
+	portal := startPortal(t, address, uint32(Dark))
+	conn := dbustest.Connect(t, address)
+
+	s, err := Read(conn)
+	if err != nil || s != Dark {
+		t.Fatalf("expected dark, got %v (%v)", s, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan Scheme, 4)
+	done := make(chan error, 1)
+	go func() { done <- Watch(ctx, conn, func(s Scheme) { got <- s }) }()
+
+	emit := func(ns, k string, value uint32) {
+		if err := portal.Emit(portalPath, settingsIface+".SettingChanged", ns, k, dbus.MakeVariant(value)); err != nil {
+			t.Fatalf("emit: %v", err)
+		}
+	}
+	// The match rule is installed asynchronously; keep emitting until the
+	// watcher sees the first signal.
+	deadline := time.After(5 * time.Second)
+	for first := false; !first; {
+		emit(namespace, key, uint32(Light))
+		select {
+		case s := <-got:
+			if s != Light {
+				t.Fatalf("expected light, got %v", s)
+			}
+			first = true
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no signal received")
+		}
+	}
+
+	emit("org.gnome.desktop.interface", key, uint32(NoPreference))
+	emit(namespace, "accent-color", 7)
+	emit(namespace, key, uint32(Dark))
+	for s := Light; s != Dark; {
+		select {
+		case s = <-got:
+			// Late copies of the first signal may still arrive.
+			if s != Light && s != Dark {
+				t.Fatalf("expected only color-scheme changes, got %v", s)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no signal received")
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+}
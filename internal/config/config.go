@@ -0,0 +1,185 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const fileName = "config.json"
+
+// Config is the optional wugo configuration file. Command-line flags take
+// precedence over values set here.
+type Config struct {
+	HTTP       HTTP            `json:"http"`
+	Sources    Sources         `json:"sources"`
+	Library    Library         `json:"library"`
+	Schedule   []ScheduleEntry `json:"schedule"`
+	Location   *Location       `json:"location"`
+	Appearance Appearance      `json:"appearance"`
+	Palette    Palette         `json:"palette"`
+	Hooks      Hooks           `json:"hooks"`
+}
+
+// Hooks are commands run before a download, after a wallpaper was set and
+// when fetching or setting one failed.
+type Hooks struct {
+	PreDownload []Hook `json:"pre_download"`
+	PostSet     []Hook `json:"post_set"`
+	OnFailure   []Hook `json:"on_failure"`
+}
+
+// Hook is a shell command. Timeout is a duration such as "10s" and
+// defaults to 30s. Only a failing Required hook fails the command; a
+// failing required pre_download hook cancels the download.
+type Hook struct {
+	Name     string `json:"name"`
+	Command  string `json:"command"`
+	Timeout  string `json:"timeout"`
+	Required bool   `json:"required"`
+}
+
+// Palette configures the color schemes exported by "wugo palette --export"
+// and, when Auto is set, after every wallpaper change. Dir defaults to
+// ~/.cache/wugo/palette and Formats to all of them.
+type Palette struct {
+	Auto    bool     `json:"auto"`
+	Dir     string   `json:"dir"`
+	Formats []string `json:"formats"`
+}
+
+// Appearance pairs light and dark variants of library images, which
+// "wugo appearance follow" swaps when the desktop color scheme changes.
+type Appearance struct {
+	Pairs []Pair `json:"pairs"`
+}
+
+type Pair struct {
+	Light string `json:"light"`
+	Dark  string `json:"dark"`
+}
+
+// Location is where sun-based schedule entries compute sunrise and sunset,
+// in degrees with north and east positive.
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// ScheduleEntry shows an image, a random image from a folder or a random
+// library image with a tag, either during a daily time range such as
+// "06:00-12:00", from each match of a cron expression until the next entry
+// starts, or while the sun is up ("day") or down ("night").
+type ScheduleEntry struct {
+	Name   string `json:"name"`
+	Time   string `json:"time"`
+	Cron   string `json:"cron"`
+	Sun    string `json:"sun"`
+	Image  string `json:"image"`
+	Folder string `json:"folder"`
+	Tag    string `json:"tag"`
+}
+
+type Library struct {
+	Prune Prune `json:"prune"`
+}
+
+// Prune is the retention policy used by "wugo prune" and, when Every is
+// set, applied periodically by the daemon. Sizes look like "5G", ages like
+// "90d".
+type Prune struct {
+	KeepLast  int    `json:"keep_last"`
+	MaxSize   string `json:"max_size"`
+	OlderThan string `json:"older_than"`
+	Every     string `json:"every"`
+}
+
+type HTTP struct {
+	UserAgent  string                       `json:"user_agent"`
+	Referer    string                       `json:"referer"`
+	CookieFile string                       `json:"cookie_file"`
+	Proxy      string                       `json:"proxy"`
+	CAFile     string                       `json:"ca_file"`
+	Offline    bool                         `json:"offline"`
+	Headers    map[string]string            `json:"headers"`
+	Hosts      map[string]map[string]string `json:"hosts"`
+}
+
+type Sources struct {
+	Wallhaven Wallhaven            `json:"wallhaven"`
+	API       map[string]APISource `json:"api"`
+	Bing      Bing                 `json:"bing"`
+	APOD      APOD                 `json:"apod"`
+	Reddit    Reddit               `json:"reddit"`
+	S3        S3                   `json:"s3"`
+}
+
+// S3 overrides the endpoint, region and profile otherwise taken from the
+// standard AWS environment variables and files.
+type S3 struct {
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region"`
+	Profile  string `json:"profile"`
+}
+
+type Reddit struct {
+	MinResolution string `json:"min_resolution"`
+	AllowNSFW     bool   `json:"allow_nsfw"`
+}
+
+type Bing struct {
+	Market string `json:"market"`
+}
+
+type APOD struct {
+	APIKey string `json:"api_key"`
+}
+
+type Wallhaven struct {
+	APIKey  string `json:"api_key"`
+	Purity  string `json:"purity"`
+	Ratio   string `json:"ratio"`
+	AtLeast string `json:"atleast"`
+}
+
+// APISource describes a JSON endpoint queried by the api:<name> input.
+// Selectors use a dotted JSONPath subset such as "urls.full" or
+// "results[*].url".
+type APISource struct {
+	URL         string `json:"url"`
+	Image       string `json:"image"`
+	Author      string `json:"author"`
+	Attribution string `json:"attribution"`
+	Title       string `json:"title"`
+}
+
+// Path returns the config file location inside the user config directory.
+func Path(configDir func() (string, error)) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "wugo", fileName), nil
+}
+
+// Load reads the config file at path. A missing file yields an empty config.
+func Load(path string) (Config, error) {
+	var cfg Config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	got, err := Path(func() (string, error) { return "/home/test/.config", nil })
+	if err != nil {
+		t.Fatalf("path: %v", err)
+	}
+	if got != filepath.Join("/home/test/.config", "wugo", "config.json") {
+		t.Fatalf("unexpected path: %s", got)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.HTTP.UserAgent != "" {
+		t.Fatalf("expected empty config, got %+v", cfg)
+	}
+}
+
+func TestLoadHTTP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"http": {"user_agent": "wugo/1", "hosts": {"example.com": {"Referer": "https://example.com/"}}}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.HTTP.UserAgent != "wugo/1" {
+		t.Fatalf("unexpected user agent: %s", cfg.HTTP.UserAgent)
+	}
+	if cfg.HTTP.Hosts["example.com"]["Referer"] != "https://example.com/" {
+		t.Fatalf("unexpected host rules: %+v", cfg.HTTP.Hosts)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+func TestLoadLibrary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"library": {"prune": {"keep_last": 200, "max_size": "5G", "older_than": "90d", "every": "24h"}}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	want := Prune{KeepLast: 200, MaxSize: "5G", OlderThan: "90d", Every: "24h"}
+	if cfg.Library.Prune != want {
+		t.Fatalf("unexpected prune config: %+v", cfg.Library.Prune)
+	}
+}
@@ -0,0 +1,224 @@
+// Package dynamic reads dynamic wallpapers: sets of images shown at times
+// of day or sun elevations, with crossfades between them.
+package dynamic
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wugo/internal/config"
+)
+
+// maxExtractSize caps how much a zip archive may unpack to.
+var maxExtractSize int64 = 1 << 30
+
+// Bundle is a loaded dynamic wallpaper.
+type Bundle struct {
+	Name     string
+	timeline *timeline
+	cycle    *Cycle
+}
+
+// Load reads a bundle from a directory, a zip archive, a manifest.json or
+// a GNOME background.xml. Zip archives are extracted below extractDir.
+// Sun elevation frames need loc.
+func Load(path, extractDir string, loc *config.Location) (*Bundle, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	if !info.IsDir() && strings.EqualFold(filepath.Ext(path), ".zip") {
+		dir, err := extract(path, extractDir)
+		if err != nil {
+			return nil, fmt.Errorf("extract %s: %w", filepath.Base(path), err)
+		}
+		path = dir
+		info, err = os.Stat(dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	manifest := path
+	if info.IsDir() {
+		if manifest, err = findManifest(path); err != nil {
+			return nil, err
+		}
+	}
+
+	b := &Bundle{Name: name}
+	if strings.EqualFold(filepath.Ext(manifest), ".xml") {
+		c, err := loadGNOME(manifest)
+		if err != nil {
+			return nil, err
+		}
+		b.cycle = &c
+		return b, nil
+	}
+
+	if b.timeline, err = loadManifest(manifest, loc); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Cycle returns the cycle in effect at t. For manifests it covers the day
+// around t; sun elevation frames move from day to day.
+func (b *Bundle) Cycle(t time.Time) (Cycle, error) {
+	if b.cycle != nil {
+		return *b.cycle, nil
+	}
+	return b.timeline.cycle(t)
+}
+
+func findManifest(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var xmlFile string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if isManifest(e.Name()) {
+			return filepath.Join(dir, e.Name()), nil
+		}
+		if xmlFile == "" && strings.EqualFold(filepath.Ext(e.Name()), ".xml") {
+			xmlFile = filepath.Join(dir, e.Name())
+		}
+	}
+	if xmlFile == "" {
+		return "", fmt.Errorf("%s: no manifest.json or background XML", dir)
+	}
+	return xmlFile, nil
+}
+
+// extract unpacks a zip archive into a directory below extractDir named
+// after the archive and its contents, so an unchanged archive is only
+// unpacked once. Copies of earlier versions of the archive are removed. A
+// single top-level directory in the archive is descended into.
+func extract(path, extractDir string) (string, error) {
+	sum, err := hashFile(path)
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	dest := filepath.Join(extractDir, name+"-"+sum)
+
+	if _, err := os.Stat(dest); errors.Is(err, os.ErrNotExist) {
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return "", err
+		}
+		defer zr.Close()
+
+		var total uint64
+		for _, f := range zr.File {
+			total += f.UncompressedSize64
+		}
+		if total > uint64(maxExtractSize) {
+			return "", fmt.Errorf("archive unpacks to more than %d MiB", maxExtractSize>>20)
+		}
+
+		tmp := dest + ".tmp"
+		_ = os.RemoveAll(tmp)
+		// Sizes in the archive may lie, so the copies are limited as well.
+		remaining := maxExtractSize
+		for _, f := range zr.File {
+			if err := extractFile(f, tmp, &remaining); err != nil {
+				_ = os.RemoveAll(tmp)
+				return "", err
+			}
+		}
+		if err := os.Rename(tmp, dest); err != nil {
+			return "", err
+		}
+	}
+	removeStale(extractDir, name, filepath.Base(dest))
+
+	entries, err := os.ReadDir(dest)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(dest, entries[0].Name()), nil
+	}
+	return dest, nil
+}
+
+// hashFile returns a short content hash of the file at path.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)[:4]), nil
+}
+
+// removeStale deletes the extracted copies of other versions of the archive
+// called name, keeping keep.
+func removeStale(extractDir, name, keep string) {
+	entries, err := os.ReadDir(extractDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		sum, ok := strings.CutPrefix(e.Name(), name+"-")
+		sum = strings.TrimSuffix(sum, ".tmp")
+		if !ok || e.Name() == keep || len(sum) != 8 || strings.Trim(sum, "0123456789abcdef") != "" {
+			continue
+		}
+		_ = os.RemoveAll(filepath.Join(extractDir, e.Name()))
+	}
+}
+
+// extractFile writes one archive entry below dir, taking its size from
+// remaining.
+func extractFile(f *zip.File, dir string, remaining *int64) error {
+	target := filepath.Join(dir, filepath.FromSlash(f.Name))
+	if rel, err := filepath.Rel(dir, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("unsafe path in archive: %s", f.Name)
+	}
+	if f.FileInfo().IsDir() {
+		return os.MkdirAll(target, 0o755)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(dst, io.LimitReader(src, *remaining+1))
+	if err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if *remaining -= n; *remaining < 0 {
+		_ = dst.Close()
+		return fmt.Errorf("archive unpacks to more than %d MiB", maxExtractSize>>20)
+	}
+	return dst.Close()
+}
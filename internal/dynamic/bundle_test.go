@@ -0,0 +1,189 @@
+package dynamic
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"wugo/internal/config"
+)
+
+const sampleManifest = `{
+  "crossfade": "1h",
+  "frames": [
+    {"image": "morning.jpg", "time": "06:00"},
+    {"image": "noon.jpg", "time": "12:00"},
+    {"image": "night.jpg", "time": "20:00"}
+  ]
+}`
+
+func TestLoadDirectoryManifest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(sampleManifest), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	b, err := Load(dir, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	tests := []struct {
+		hour, minute int
+		from, to     string
+		progress     float64
+	}{
+		{7, 0, "morning.jpg", "", 0},
+		{11, 30, "morning.jpg", "noon.jpg", 0.5},
+		{21, 0, "night.jpg", "", 0},
+		{3, 0, "night.jpg", "", 0},
+		{5, 15, "night.jpg", "morning.jpg", 0.25},
+	}
+	for _, tt := range tests {
+		at := time.Date(2024, 6, 1, tt.hour, tt.minute, 0, 0, time.UTC)
+		c, err := b.Cycle(at)
+		if err != nil {
+			t.Fatalf("cycle: %v", err)
+		}
+		st := c.At(at)
+		to := ""
+		if st.To != "" {
+			to = filepath.Base(st.To)
+		}
+		if filepath.Base(st.From) != tt.from || to != tt.to || st.Progress != tt.progress {
+			t.Fatalf("%02d:%02d: got %+v", tt.hour, tt.minute, st)
+		}
+	}
+}
+
+func TestLoadElevationFrames(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `{"frames": [
+		{"image": "day.jpg", "elevation": 0, "rising": true},
+		{"image": "night.jpg", "elevation": 0}
+	]}`
+	path := filepath.Join(dir, "wugo.json")
+	if err := os.WriteFile(path, []byte(manifest), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if _, err := Load(path, t.TempDir(), nil); err == nil {
+		t.Fatal("expected elevation frames without a location to fail")
+	}
+
+	berlin := &config.Location{Latitude: 52.52, Longitude: 13.405}
+	b, err := Load(path, t.TempDir(), berlin)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	for hour, expected := range map[int]string{10: "day.jpg", 23: "night.jpg", 2: "night.jpg"} {
+		at := time.Date(2024, 6, 21, hour, 0, 0, 0, time.UTC)
+		c, err := b.Cycle(at)
+		if err != nil {
+			t.Fatalf("cycle: %v", err)
+		}
+		if got := filepath.Base(c.At(at).From); got != expected {
+			t.Fatalf("%02d:00 UTC: expected %s, got %s", hour, expected, got)
+		}
+	}
+}
+
+func TestLoadZip(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "mojave.zip")
+	writeZip(t, archive, map[string]string{
+		"mojave/manifest.json": sampleManifest,
+		"mojave/morning.jpg":   "x",
+	})
+
+	extractDir := t.TempDir()
+	b, err := Load(archive, extractDir, nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if b.Name != "mojave" {
+		t.Fatalf("unexpected name: %s", b.Name)
+	}
+	at := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+	c, err := b.Cycle(at)
+	if err != nil {
+		t.Fatalf("cycle: %v", err)
+	}
+	if _, err := os.Stat(c.At(at).From); err != nil {
+		t.Fatalf("expected extracted frame: %v", err)
+	}
+
+	unsafe := filepath.Join(dir, "evil.zip")
+	writeZip(t, unsafe, map[string]string{"../escape.json": "{}"})
+	if _, err := Load(unsafe, extractDir, nil); err == nil {
+		t.Fatal("expected archive escaping the extract directory to fail")
+	}
+}
+
+func TestLoadZipRemovesOldVersions(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "mojave.zip")
+	extractDir := t.TempDir()
+	for _, frame := range []string{"v1", "v2"} {
+		writeZip(t, archive, map[string]string{"manifest.json": sampleManifest, "morning.jpg": frame})
+		if _, err := Load(archive, extractDir, nil); err != nil {
+			t.Fatalf("load: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(extractDir, "mojave-night-00000000"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if _, err := Load(archive, extractDir, nil); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	entries, err := os.ReadDir(extractDir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected the latest copy and the other bundle, got %v", entries)
+	}
+}
+
+func TestLoadZipSizeLimit(t *testing.T) {
+	defer func(old int64) { maxExtractSize = old }(maxExtractSize)
+	maxExtractSize = 1 << 10
+
+	archive := filepath.Join(t.TempDir(), "bomb.zip")
+	writeZip(t, archive, map[string]string{"manifest.json": sampleManifest, "big.jpg": strings.Repeat("x", 2<<10)})
+	extractDir := t.TempDir()
+	if _, err := Load(archive, extractDir, nil); err == nil || !strings.Contains(err.Error(), "more than") {
+		t.Fatalf("expected the size limit to stop extraction, got %v", err)
+	}
+	if entries, _ := os.ReadDir(extractDir); len(entries) != 0 {
+		t.Fatalf("expected nothing left behind, got %v", entries)
+	}
+}
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create zip: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip entry: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("zip write: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("close file: %v", err)
+	}
+}
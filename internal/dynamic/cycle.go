@@ -0,0 +1,62 @@
+package dynamic
+
+import "time"
+
+// Segment shows From for Duration, or crossfades from From to To when To is
+// set.
+type Segment struct {
+	Duration time.Duration
+	From     string
+	To       string
+}
+
+// Cycle is a sequence of segments that starts at Start and repeats.
+type Cycle struct {
+	Start    time.Time
+	Segments []Segment
+}
+
+// State is what a cycle shows at a moment. Progress runs from 0 to 1
+// through a crossfade. Until is when the current segment ends.
+type State struct {
+	From     string
+	To       string
+	Progress float64
+	Since    time.Time
+	Until    time.Time
+}
+
+func (c Cycle) length() time.Duration {
+	var total time.Duration
+	for _, s := range c.Segments {
+		total += s.Duration
+	}
+	return total
+}
+
+// At returns the state of c at t.
+func (c Cycle) At(t time.Time) State {
+	total := c.length()
+	if total <= 0 {
+		if len(c.Segments) == 0 {
+			return State{}
+		}
+		return State{From: c.Segments[0].From, Since: t}
+	}
+
+	pos := t.Sub(c.Start) % total
+	if pos < 0 {
+		pos += total
+	}
+	for _, s := range c.Segments {
+		if pos < s.Duration {
+			st := State{From: s.From, To: s.To, Since: t.Add(-pos), Until: t.Add(s.Duration - pos)}
+			if s.To != "" {
+				st.Progress = float64(pos) / float64(s.Duration)
+			}
+			return st
+		}
+		pos -= s.Duration
+	}
+	return State{}
+}
@@ -0,0 +1,38 @@
+package dynamic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCycleAt(t *testing.T) {
+	start := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	c := Cycle{Start: start, Segments: []Segment{
+		{Duration: 10 * time.Hour, From: "a"},
+		{Duration: 2 * time.Hour, From: "a", To: "b"},
+		{Duration: 12 * time.Hour, From: "b"},
+	}}
+
+	tests := []struct {
+		at       time.Time
+		from, to string
+		progress float64
+	}{
+		{start.Add(time.Hour), "a", "", 0},
+		{start.Add(11 * time.Hour), "a", "b", 0.5},
+		{start.Add(13 * time.Hour), "b", "", 0},
+		{start.Add(24*time.Hour + 30*time.Minute), "a", "", 0},
+		{start.Add(-time.Hour), "b", "", 0},
+	}
+	for _, tt := range tests {
+		st := c.At(tt.at)
+		if st.From != tt.from || st.To != tt.to || st.Progress != tt.progress {
+			t.Fatalf("At(%s) = %+v, expected %s->%s at %.2f", tt.at.Format(time.Kitchen), st, tt.from, tt.to, tt.progress)
+		}
+	}
+
+	st := c.At(start.Add(11 * time.Hour))
+	if !st.Since.Equal(start.Add(10*time.Hour)) || !st.Until.Equal(start.Add(12*time.Hour)) {
+		t.Fatalf("unexpected segment bounds: %s - %s", st.Since, st.Until)
+	}
+}
@@ -0,0 +1,136 @@
+package dynamic
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gnomeBackground is GNOME's background.xml slideshow format: a start time
+// followed by static and transition items that repeat.
+type gnomeBackground struct {
+	XMLName   xml.Name       `xml:"background"`
+	StartTime gnomeStartTime `xml:"starttime"`
+	Items     []gnomeItem    `xml:",any"`
+}
+
+type gnomeStartTime struct {
+	Year   int `xml:"year"`
+	Month  int `xml:"month"`
+	Day    int `xml:"day"`
+	Hour   int `xml:"hour"`
+	Minute int `xml:"minute"`
+	Second int `xml:"second"`
+}
+
+type gnomeItem struct {
+	XMLName  xml.Name
+	Type     string     `xml:"type,attr,omitempty"`
+	Duration float64    `xml:"duration"`
+	File     *gnomeFile `xml:"file,omitempty"`
+	From     string     `xml:"from,omitempty"`
+	To       string     `xml:"to,omitempty"`
+}
+
+// gnomeFile is a path, or a set of paths by screen size.
+type gnomeFile struct {
+	Path  string      `xml:",chardata"`
+	Sizes []gnomeSize `xml:"size"`
+}
+
+type gnomeSize struct {
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+	Path   string `xml:",chardata"`
+}
+
+func (f *gnomeFile) best() string {
+	if f == nil {
+		return ""
+	}
+	path := strings.TrimSpace(f.Path)
+	largest := 0
+	for _, s := range f.Sizes {
+		if area := s.Width * s.Height; area >= largest {
+			largest, path = area, strings.TrimSpace(s.Path)
+		}
+	}
+	return path
+}
+
+// loadGNOME reads a background.xml file. Relative paths are taken relative
+// to its directory.
+func loadGNOME(path string) (Cycle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Cycle{}, err
+	}
+	var bg gnomeBackground
+	if err := xml.Unmarshal(data, &bg); err != nil {
+		return Cycle{}, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		p = strings.TrimSpace(p)
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	st := bg.StartTime
+	c := Cycle{Start: time.Date(st.Year, time.Month(st.Month), st.Day, st.Hour, st.Minute, st.Second, 0, time.Local)}
+	for _, item := range bg.Items {
+		d := time.Duration(item.Duration * float64(time.Second))
+		switch item.XMLName.Local {
+		case "static":
+			c.Segments = append(c.Segments, Segment{Duration: d, From: resolve(item.File.best())})
+		case "transition":
+			c.Segments = append(c.Segments, Segment{Duration: d, From: resolve(item.From), To: resolve(item.To)})
+		}
+	}
+	if len(c.Segments) == 0 {
+		return Cycle{}, fmt.Errorf("%s: no static or transition items", filepath.Base(path))
+	}
+	return c, nil
+}
+
+// WriteGNOME writes c as a GNOME background.xml slideshow, which GNOME
+// animates itself when set as the wallpaper.
+func WriteGNOME(w io.Writer, c Cycle) error {
+	start := c.Start.Local()
+	bg := gnomeBackground{
+		StartTime: gnomeStartTime{
+			Year: start.Year(), Month: int(start.Month()), Day: start.Day(),
+			Hour: start.Hour(), Minute: start.Minute(), Second: start.Second(),
+		},
+	}
+	for _, s := range c.Segments {
+		item := gnomeItem{Duration: s.Duration.Seconds()}
+		if s.To == "" {
+			item.XMLName.Local = "static"
+			item.File = &gnomeFile{Path: s.From}
+		} else {
+			item.XMLName.Local = "transition"
+			item.Type = "overlay"
+			item.From, item.To = s.From, s.To
+		}
+		bg.Items = append(bg.Items, item)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(bg); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
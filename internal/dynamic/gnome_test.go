@@ -0,0 +1,104 @@
+package dynamic
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleGNOME = `<?xml version="1.0"?>
+<background>
+  <starttime>
+    <year>2024</year><month>6</month><day>1</day>
+    <hour>7</hour><minute>0</minute><second>0</second>
+  </starttime>
+  <static>
+    <duration>39600.0</duration>
+    <file>day.jpg</file>
+  </static>
+  <transition type="overlay">
+    <duration>3600.0</duration>
+    <from>day.jpg</from>
+    <to>/usr/share/backgrounds/night.jpg</to>
+  </transition>
+  <static>
+    <duration>43200.0</duration>
+    <file>
+      <size width="1920" height="1080">night-1080.jpg</size>
+      <size width="3840" height="2160">night-2160.jpg</size>
+    </file>
+  </static>
+</background>
+`
+
+func TestLoadGNOME(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "background.xml")
+	if err := os.WriteFile(path, []byte(sampleGNOME), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	c, err := loadGNOME(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !c.Start.Equal(time.Date(2024, 6, 1, 7, 0, 0, 0, time.Local)) {
+		t.Fatalf("unexpected start: %s", c.Start)
+	}
+	expected := []Segment{
+		{11 * time.Hour, filepath.Join(dir, "day.jpg"), ""},
+		{time.Hour, filepath.Join(dir, "day.jpg"), "/usr/share/backgrounds/night.jpg"},
+		{12 * time.Hour, filepath.Join(dir, "night-2160.jpg"), ""},
+	}
+	if len(c.Segments) != len(expected) {
+		t.Fatalf("expected %d segments, got %+v", len(expected), c.Segments)
+	}
+	for i, s := range expected {
+		if c.Segments[i] != s {
+			t.Fatalf("segment %d: expected %+v, got %+v", i, s, c.Segments[i])
+		}
+	}
+}
+
+func TestWriteGNOMERoundTrip(t *testing.T) {
+	c := Cycle{
+		Start: time.Date(2024, 6, 1, 7, 0, 0, 0, time.Local),
+		Segments: []Segment{
+			{Duration: 11 * time.Hour, From: "/w/day.jpg"},
+			{Duration: time.Hour, From: "/w/day.jpg", To: "/w/night.jpg"},
+			{Duration: 12 * time.Hour, From: "/w/night.jpg"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGNOME(&buf, c); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "<?xml") || !strings.Contains(out, `<transition type="overlay">`) {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	if strings.Contains(out, "<from></from>") || strings.Contains(out, "<file></file>") {
+		t.Fatalf("unexpected empty elements:\n%s", out)
+	}
+
+	path := filepath.Join(t.TempDir(), "out.xml")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	got, err := loadGNOME(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if !got.Start.Equal(c.Start) || len(got.Segments) != len(c.Segments) {
+		t.Fatalf("round trip mismatch: %+v", got)
+	}
+	for i := range c.Segments {
+		if got.Segments[i] != c.Segments[i] {
+			t.Fatalf("segment %d: expected %+v, got %+v", i, c.Segments[i], got.Segments[i])
+		}
+	}
+}
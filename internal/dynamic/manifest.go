@@ -0,0 +1,153 @@
+package dynamic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"wugo/internal/config"
+	"wugo/internal/sun"
+)
+
+// Manifest is a bundle's JSON description. Frames appear at a time of day
+// or when the sun passes an elevation, rising in the morning or setting in
+// the evening.
+type Manifest struct {
+	Crossfade string  `json:"crossfade"`
+	Frames    []Frame `json:"frames"`
+}
+
+type Frame struct {
+	Image     string   `json:"image"`
+	Time      string   `json:"time"`
+	Elevation *float64 `json:"elevation"`
+	Rising    bool     `json:"rising"`
+}
+
+// timeline is a parsed manifest whose frames are laid out per day.
+type timeline struct {
+	frames    []Frame
+	crossfade time.Duration
+	loc       *config.Location
+}
+
+func loadManifest(path string, loc *config.Location) (*timeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	if len(m.Frames) == 0 {
+		return nil, fmt.Errorf("%s: no frames", filepath.Base(path))
+	}
+
+	tl := &timeline{loc: loc}
+	if m.Crossfade != "" {
+		if tl.crossfade, err = time.ParseDuration(m.Crossfade); err != nil || tl.crossfade < 0 {
+			return nil, fmt.Errorf("crossfade %q: expected a duration such as 20m", m.Crossfade)
+		}
+	}
+
+	dir := filepath.Dir(path)
+	for i, f := range m.Frames {
+		if f.Image == "" {
+			return nil, fmt.Errorf("frame %d: missing image", i+1)
+		}
+		if (f.Time == "") == (f.Elevation == nil) {
+			return nil, fmt.Errorf("frame %d: set exactly one of time and elevation", i+1)
+		}
+		if f.Time != "" {
+			if _, err := time.Parse("15:04", f.Time); err != nil {
+				return nil, fmt.Errorf("frame %d: invalid time %q, expected HH:MM", i+1, f.Time)
+			}
+		}
+		if f.Elevation != nil && loc == nil {
+			return nil, fmt.Errorf("frame %d: sun elevation frames need a location", i+1)
+		}
+		if !filepath.IsAbs(f.Image) {
+			f.Image = filepath.Join(dir, f.Image)
+		}
+		tl.frames = append(tl.frames, f)
+	}
+	return tl, nil
+}
+
+type placed struct {
+	at    time.Time
+	image string
+}
+
+// day lays the frames out on the calendar day of t. Elevation frames the
+// sun does not reach that day are left out.
+func (tl *timeline) day(t time.Time) []placed {
+	y, m, d := t.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+
+	var out []placed
+	for _, f := range tl.frames {
+		if f.Time != "" {
+			clock, _ := time.Parse("15:04", f.Time)
+			out = append(out, placed{time.Date(y, m, d, clock.Hour(), clock.Minute(), 0, 0, t.Location()), f.Image})
+			continue
+		}
+		morning, evening, ok := sun.AtElevation(midnight, tl.loc.Latitude, tl.loc.Longitude, *f.Elevation)
+		if !ok {
+			continue
+		}
+		at := evening
+		if f.Rising {
+			at = morning
+		}
+		out = append(out, placed{at, f.Image})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].at.Before(out[j].at) })
+	return out
+}
+
+// cycle returns the day-long cycle in effect at t: the one laid out on t's
+// day, or the previous day's before the first frame.
+func (tl *timeline) cycle(t time.Time) (Cycle, error) {
+	frames := tl.day(t)
+	if len(frames) > 0 && t.Before(frames[0].at) {
+		frames = tl.day(t.AddDate(0, 0, -1))
+	}
+	if len(frames) == 0 {
+		return Cycle{}, fmt.Errorf("no frames on %s", t.Format("2006-01-02"))
+	}
+
+	c := Cycle{Start: frames[0].at}
+	for i, f := range frames {
+		next := frames[0].at.Add(24 * time.Hour)
+		nextImage := frames[0].image
+		if i+1 < len(frames) {
+			next, nextImage = frames[i+1].at, frames[i+1].image
+		}
+		gap := next.Sub(f.at)
+		fade := min(tl.crossfade, gap/2)
+		if nextImage == f.image {
+			fade = 0
+		}
+		if gap-fade > 0 {
+			c.Segments = append(c.Segments, Segment{Duration: gap - fade, From: f.image})
+		}
+		if fade > 0 {
+			c.Segments = append(c.Segments, Segment{Duration: fade, From: f.image, To: nextImage})
+		}
+	}
+	return c, nil
+}
+
+func isManifest(name string) bool {
+	switch strings.ToLower(name) {
+	case "manifest.json", "wugo.json":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+// Package hooks runs user-defined commands around wallpaper changes.
+package hooks
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"wugo/internal/config"
+)
+
+// Hook points.
+const (
+	PreDownload = "pre_download"
+	PostSet     = "post_set"
+	OnFailure   = "on_failure"
+)
+
+// DefaultTimeout is how long a hook may run when it sets no timeout.
+const DefaultTimeout = 30 * time.Second
+
+// waitDelay is how long to wait for a hook's output after it exited or was
+// killed, in case it left children holding the pipes open.
+const waitDelay = time.Second
+
+// Event describes what a hook runs for. Hooks get it as WUGO_* environment
+// variables and as JSON on stdin.
+type Event struct {
+	Hook    string   `json:"hook"`
+	Image   string   `json:"image,omitempty"`
+	Source  string   `json:"source,omitempty"`
+	Backend string   `json:"backend,omitempty"`
+	Targets []string `json:"targets,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+func (e Event) env() []string {
+	return []string{
+		"WUGO_HOOK=" + e.Hook,
+		"WUGO_IMAGE=" + e.Image,
+		"WUGO_SOURCE=" + e.Source,
+		"WUGO_BACKEND=" + e.Backend,
+		"WUGO_TARGETS=" + strings.Join(e.Targets, ","),
+		"WUGO_ERROR=" + e.Error,
+	}
+}
+
+// Run runs each hook in order through sh with its output going to out.
+// Failures of optional hooks are reported to out as warnings. All hooks
+// run; the error of the first failing required hook is returned.
+func Run(ctx context.Context, hooks []config.Hook, ev Event, out io.Writer) error {
+	var failed error
+	for _, h := range hooks {
+		err := run(ctx, h, ev, out)
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("%s hook %q: %w", ev.Hook, cmp.Or(h.Name, h.Command), err)
+		if !h.Required {
+			fmt.Fprintln(out, "Warning:", err)
+		} else if failed == nil {
+			failed = err
+		}
+	}
+	return failed
+}
+
+func run(ctx context.Context, h config.Hook, ev Event, out io.Writer) error {
+	if strings.TrimSpace(h.Command) == "" {
+		return errors.New("missing command")
+	}
+	timeout := DefaultTimeout
+	if h.Timeout != "" {
+		d, err := time.ParseDuration(h.Timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q", h.Timeout)
+		}
+		timeout = d
+	}
+	input, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Env = append(os.Environ(), ev.env()...)
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = waitDelay
+	killGroup(cmd)
+
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
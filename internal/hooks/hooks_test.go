@@ -0,0 +1,73 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"wugo/internal/config"
+)
+
+func TestRunPassesEventAsEnvAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	stdin := filepath.Join(dir, "stdin.json")
+	ev := Event{Hook: PostSet, Image: "/w/forest.jpg", Source: "https://example.com/forest.jpg", Backend: "kde", Targets: []string{"desktop", "lockscreen"}}
+
+	var out bytes.Buffer
+	err := Run(context.Background(), []config.Hook{
+		{Command: `echo "$WUGO_HOOK $WUGO_IMAGE $WUGO_BACKEND $WUGO_TARGETS"; cat > ` + stdin},
+	}, ev, &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "post_set /w/forest.jpg kde desktop,lockscreen" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+
+	data, err := os.ReadFile(stdin)
+	if err != nil {
+		t.Fatalf("read stdin copy: %v", err)
+	}
+	var got Event
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("parse stdin: %v", err)
+	}
+	if got.Source != ev.Source || len(got.Targets) != 2 {
+		t.Fatalf("unexpected event on stdin: %+v", got)
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	var out bytes.Buffer
+	err := Run(context.Background(), []config.Hook{
+		{Name: "optional", Command: "exit 3"},
+		{Name: "slow", Command: "sleep 5", Timeout: "100ms", Required: true},
+		{Name: "after", Command: "echo still running"},
+	}, Event{Hook: PostSet}, &out)
+
+	if err == nil || !strings.Contains(err.Error(), `post_set hook "slow": timed out after 100ms`) {
+		t.Fatalf("expected the required hook's timeout, got %v", err)
+	}
+	if !strings.Contains(out.String(), `Warning: post_set hook "optional": exit status 3`) {
+		t.Fatalf("expected a warning for the optional hook: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "still running") {
+		t.Fatalf("expected later hooks to run: %s", out.String())
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := Run(ctx, []config.Hook{{Command: "sleep 5", Required: true}}, Event{Hook: PreDownload}, &bytes.Buffer{})
+	if err == nil || time.Since(start) > 3*time.Second {
+		t.Fatalf("expected a prompt cancellation error, got %v after %s", err, time.Since(start))
+	}
+}
@@ -0,0 +1,171 @@
+// Package httpclient builds the HTTP clients shared by the image processor
+// and the remote sources.
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+const DefaultTimeout = 30 * time.Second
+
+// ErrOffline is returned for every request made by an offline client.
+var ErrOffline = errors.New("offline mode: network access disabled")
+
+type Config struct {
+	Timeout time.Duration
+	// Proxy is an http, https, socks5 or socks5h URL. When empty the
+	// standard proxy environment variables are used.
+	Proxy string
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile  string
+	Offline bool
+	Jar     http.CookieJar
+}
+
+// New returns a client whose transport is built from cfg.
+func New(cfg Config) (*http.Client, error) {
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &http.Client{Transport: transport, Timeout: timeout, Jar: cfg.Jar}, nil
+}
+
+// NewTransport builds the round tripper described by cfg.
+func NewTransport(cfg Config) (http.RoundTripper, error) {
+	if cfg.Offline {
+		return offlineTransport{}, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if err := configureProxy(transport, cfg.Proxy); err != nil {
+		return nil, err
+	}
+
+	if cfg.CAFile != "" {
+		pool, err := loadCAFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return transport, nil
+}
+
+func configureProxy(transport *http.Transport, rawURL string) error {
+	if rawURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("parse proxy URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		transport.Proxy = http.ProxyURL(u)
+	case "socks5", "socks5h":
+		dialer, err := proxy.FromURL(u, proxy.Direct)
+		if err != nil {
+			return fmt.Errorf("socks proxy: %w", err)
+		}
+		transport.Proxy = nil
+		transport.DialContext = contextDialer(dialer)
+	default:
+		return fmt.Errorf("unsupported proxy scheme: %s", u.Scheme)
+	}
+
+	return nil
+}
+
+// DialFunc opens a network connection, like net.Dialer.DialContext.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// NewDialer returns how non-HTTP sources such as SFTP connect: through the
+// socks proxy of cfg, or the one $ALL_PROXY names when cfg has none. An
+// offline dialer fails with ErrOffline, and one for an http proxy fails
+// too, as such proxies only carry HTTP.
+func NewDialer(cfg Config) (DialFunc, error) {
+	if cfg.Offline {
+		return func(context.Context, string, string) (net.Conn, error) {
+			return nil, ErrOffline
+		}, nil
+	}
+	if cfg.Proxy == "" {
+		return contextDialer(proxy.FromEnvironment()), nil
+	}
+
+	u, err := url.Parse(cfg.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("parse proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return func(context.Context, string, string) (net.Conn, error) {
+			return nil, fmt.Errorf("cannot connect through %s proxy %s", u.Scheme, u.Host)
+		}, nil
+	case "socks5", "socks5h":
+		dialer, err := proxy.FromURL(u, proxy.Direct)
+		if err != nil {
+			return nil, fmt.Errorf("socks proxy: %w", err)
+		}
+		return contextDialer(dialer), nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme: %s", u.Scheme)
+	}
+}
+
+func contextDialer(dialer proxy.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if cd, ok := dialer.(proxy.ContextDialer); ok {
+		return cd.DialContext
+	}
+	return func(_ context.Context, network, addr string) (net.Conn, error) {
+		return dialer.Dial(network, addr)
+	}
+}
+
+func loadCAFile(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CA file: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+
+	return pool, nil
+}
+
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return nil, ErrOffline
+}
@@ -0,0 +1,246 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestOfflineRefusesRequests(t *testing.T) {
+	client, err := New(Config{Offline: true})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	_, err = client.Get("http://example.test/image.jpg")
+	if !errors.Is(err, ErrOffline) {
+		t.Fatalf("expected ErrOffline, got %v", err)
+	}
+}
+
+func TestHTTPProxy(t *testing.T) {
+	var gotURL string
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.String()
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	defer proxyServer.Close()
+
+	client, err := New(Config{Proxy: proxyServer.URL})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	body := get(t, client, "http://images.test/pic.jpg")
+	if body != "via proxy" {
+		t.Fatalf("unexpected body: %s", body)
+	}
+	if gotURL != "http://images.test/pic.jpg" {
+		t.Fatalf("proxy saw unexpected URL: %s", gotURL)
+	}
+}
+
+func TestSOCKS5Proxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("via socks"))
+	}))
+	defer target.Close()
+
+	socksAddr, connects := startSOCKS5(t)
+
+	client, err := New(Config{Proxy: "socks5://" + socksAddr})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	body := get(t, client, target.URL)
+	if body != "via socks" {
+		t.Fatalf("unexpected body: %s", body)
+	}
+	if got := <-connects; got != target.Listener.Addr().String() {
+		t.Fatalf("socks proxy connected to %s", got)
+	}
+}
+
+func TestDialerUsesSOCKS5Proxy(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer target.Close()
+	socksAddr, connects := startSOCKS5(t)
+
+	dial, err := NewDialer(Config{Proxy: "socks5://" + socksAddr})
+	if err != nil {
+		t.Fatalf("new dialer: %v", err)
+	}
+	conn, err := dial(context.Background(), "tcp", target.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	_ = conn.Close()
+	if got := <-connects; got != target.Addr().String() {
+		t.Fatalf("socks proxy connected to %s", got)
+	}
+}
+
+func TestOfflineDialerRefuses(t *testing.T) {
+	dial, err := NewDialer(Config{Offline: true, Proxy: "socks5://127.0.0.1:1"})
+	if err != nil {
+		t.Fatalf("new dialer: %v", err)
+	}
+	if _, err := dial(context.Background(), "tcp", "127.0.0.1:22"); !errors.Is(err, ErrOffline) {
+		t.Fatalf("expected ErrOffline, got %v", err)
+	}
+}
+
+func TestCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("trusted"))
+	}))
+	defer server.Close()
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caPath, certPEM, 0o644); err != nil {
+		t.Fatalf("write CA: %v", err)
+	}
+
+	untrusted, err := New(Config{})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if _, err := untrusted.Get(server.URL); err == nil {
+		t.Fatal("expected TLS error without CA file")
+	}
+
+	client, err := New(Config{CAFile: caPath})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if body := get(t, client, server.URL); body != "trusted" {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	if _, err := New(Config{Proxy: "ftp://proxy.test"}); err == nil {
+		t.Fatal("expected unsupported scheme error")
+	}
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caPath, []byte("not a cert"), 0o644); err != nil {
+		t.Fatalf("write CA: %v", err)
+	}
+	if _, err := New(Config{CAFile: caPath}); err == nil {
+		t.Fatal("expected invalid CA error")
+	}
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return string(data)
+}
+
+// startSOCKS5 runs a minimal no-auth SOCKS5 CONNECT proxy and reports the
+// address of every connection it opens.
+func startSOCKS5(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	connects := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(conn, connects)
+		}
+	}()
+
+	return ln.Addr().String(), connects
+}
+
+func serveSOCKS5(conn net.Conn, connects chan<- string) {
+	defer conn.Close()
+
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return
+	}
+
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		ip := make([]byte, 4)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return
+		}
+		host = net.IP(ip).String()
+	case 3:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return
+		}
+		name := make([]byte, size[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return
+		}
+		host = string(name)
+	default:
+		return
+	}
+	portBuf := make([]byte, 2)
+	if _, err := io.ReadFull(conn, portBuf); err != nil {
+		return
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBuf))))
+
+	upstream, err := net.Dial("tcp", addr)
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	connects <- addr
+
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+
+	go func() { _, _ = io.Copy(upstream, conn) }()
+	_, _ = io.Copy(conn, upstream)
+}
@@ -0,0 +1,105 @@
+package image
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const (
+	stateDirName  = ".wugo"
+	cacheFileName = "cache.json"
+)
+
+// StateDir returns the directory inside saveDir where wugo keeps its
+// indexes and other bookkeeping files.
+func StateDir(saveDir string) string {
+	return filepath.Join(saveDir, stateDirName)
+}
+
+// cacheEntry records which file in the save directory a URL was stored as,
+// together with the validators needed for conditional requests.
+type cacheEntry struct {
+	File         string `json:"file"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+func (e cacheEntry) applyConditional(req *http.Request) {
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
+
+func cacheEntryFromResponse(resp *http.Response) cacheEntry {
+	return cacheEntry{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+}
+
+// urlCache is the URL to file index kept in the save directory.
+type urlCache struct {
+	path    string
+	saveDir string
+	Entries map[string]cacheEntry `json:"entries"`
+}
+
+func loadURLCache(saveDir string) (*urlCache, error) {
+	c := &urlCache{
+		path:    filepath.Join(StateDir(saveDir), cacheFileName),
+		saveDir: saveDir,
+		Entries: map[string]cacheEntry{},
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return c, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	if c.Entries == nil {
+		c.Entries = map[string]cacheEntry{}
+	}
+
+	return c, nil
+}
+
+// lookup returns the cached file for rawURL if it still exists on disk.
+func (c *urlCache) lookup(rawURL string) (string, cacheEntry, bool) {
+	entry, ok := c.Entries[rawURL]
+	if !ok || entry.File == "" {
+		return "", cacheEntry{}, false
+	}
+
+	filePath := filepath.Join(c.saveDir, entry.File)
+	if _, err := os.Stat(filePath); err != nil {
+		return "", cacheEntry{}, false
+	}
+
+	return filePath, entry, true
+}
+
+func (c *urlCache) store(rawURL, filePath string, entry cacheEntry) error {
+	entry.File = filepath.Base(filePath)
+	c.Entries[rawURL] = entry
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0o644)
+}
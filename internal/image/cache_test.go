@@ -0,0 +1,188 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"wugo/internal/httpclient"
+)
+
+func TestDownloadRecordsCacheEntry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	saveDir := t.TempDir()
+	proc := NewProcessor(server.Client(), bytes.NewReader([]byte{1, 2, 3}))
+	imageURL := server.URL + "/daily.png"
+
+	got, err := proc.Process(context.Background(), imageURL, saveDir, false)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+
+	cache, err := loadURLCache(saveDir)
+	if err != nil {
+		t.Fatalf("load cache: %v", err)
+	}
+	cached, _, ok := cache.lookup(imageURL)
+	if !ok || cached != got {
+		t.Fatalf("expected cache entry %s, got %s (ok=%v)", got, cached, ok)
+	}
+}
+
+func TestOfflineUsesCache(t *testing.T) {
+	saveDir := t.TempDir()
+	offline, err := httpclient.New(httpclient.Config{Offline: true})
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	proc := NewProcessor(offline, bytes.NewReader([]byte{1, 2, 3}))
+
+	_, err = proc.Process(context.Background(), "https://example.test/miss.jpg", saveDir, false)
+	if !errors.Is(err, httpclient.ErrOffline) {
+		t.Fatalf("expected offline error, got %v", err)
+	}
+
+	cache, err := loadURLCache(saveDir)
+	if err != nil {
+		t.Fatalf("load cache: %v", err)
+	}
+	filePath := filepath.Join(saveDir, "hit_abcdef.jpg")
+	if err := os.WriteFile(filePath, []byte("jpg"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := cache.store("https://example.test/hit.jpg", filePath, cacheEntry{}); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	got, err := proc.Process(context.Background(), "https://example.test/hit.jpg", saveDir, false)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if got != filePath {
+		t.Fatalf("expected cached file %s, got %s", filePath, got)
+	}
+}
+
+func TestDownloadConditionalRequests(t *testing.T) {
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match")+"|"+r.Header.Get("If-Modified-Since"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		_, _ = w.Write([]byte("jpg"))
+	}))
+	defer server.Close()
+
+	saveDir := t.TempDir()
+	imageURL := server.URL + "/potd.jpg"
+	ctx := context.Background()
+
+	first, err := NewProcessor(server.Client(), bytes.NewReader([]byte{1, 2, 3})).Process(ctx, imageURL, saveDir, false)
+	if err != nil {
+		t.Fatalf("first process: %v", err)
+	}
+
+	second, err := NewProcessor(server.Client(), bytes.NewReader([]byte{4, 5, 6})).Process(ctx, imageURL, saveDir, false)
+	if err != nil {
+		t.Fatalf("second process: %v", err)
+	}
+	if second != first {
+		t.Fatalf("expected cached file %s on 304, got %s", first, second)
+	}
+
+	recorder := &releasingRecorder{}
+	refreshed, err := NewProcessor(server.Client(), bytes.NewReader([]byte{7, 8, 9}),
+		WithRefresh(true), WithRecorder(recorder)).Process(ctx, imageURL, saveDir, false)
+	if err != nil {
+		t.Fatalf("refresh process: %v", err)
+	}
+	if refreshed == first {
+		t.Fatalf("expected the refreshed download under a new name, got %s", refreshed)
+	}
+	if len(recorder.released) != 1 || recorder.released[0] != first {
+		t.Fatalf("expected %s to be released, got %v", first, recorder.released)
+	}
+	cache, err := loadURLCache(saveDir)
+	if err != nil {
+		t.Fatalf("load cache: %v", err)
+	}
+	if got, _, ok := cache.lookup(imageURL); !ok || got != refreshed {
+		t.Fatalf("expected the cache to point at %s, got %s", refreshed, got)
+	}
+
+	want := []string{"|", `"v1"|Mon, 02 Jan 2006 15:04:05 GMT`, "|"}
+	if len(conditional) != len(want) {
+		t.Fatalf("unexpected request count: %v", conditional)
+	}
+	for i := range want {
+		if conditional[i] != want[i] {
+			t.Fatalf("request %d: expected %q, got %q", i, want[i], conditional[i])
+		}
+	}
+}
+
+type releasingRecorder struct {
+	fakeRecorder
+	released []string
+}
+
+func (r *releasingRecorder) Release(_, path string) error {
+	r.released = append(r.released, path)
+	return nil
+}
+
+func TestDownloadNotModifiedWithoutCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	proc := NewProcessor(server.Client(), bytes.NewReader([]byte{1, 2, 3}))
+	if _, err := proc.Process(context.Background(), server.URL+"/x.jpg", t.TempDir(), false); err == nil {
+		t.Fatal("expected error for unexpected 304")
+	}
+}
+
+func TestDownloadRemovesFileWhenCacheFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	saveDir := t.TempDir()
+	// A dangling link reads as a missing cache but cannot be written.
+	if err := os.Mkdir(StateDir(saveDir), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(saveDir, "missing", "cache.json"), filepath.Join(StateDir(saveDir), cacheFileName)); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	proc := NewProcessor(server.Client(), bytes.NewReader([]byte{1, 2, 3}))
+	if _, err := proc.Process(context.Background(), server.URL+"/daily.png", saveDir, false); err == nil {
+		t.Fatal("expected cache error")
+	}
+	entries, err := os.ReadDir(saveDir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != stateDirName {
+		t.Fatalf("expected the download removed, got %v", entries)
+	}
+}
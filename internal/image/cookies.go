@@ -0,0 +1,98 @@
+package image
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const httpOnlyPrefix = "#HttpOnly_"
+
+// LoadCookieFile reads a Netscape cookies.txt file into a cookie jar.
+func LoadCookieFile(path string) (http.CookieJar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		cookie, cookieURL, ok, err := parseCookieLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		if ok {
+			jar.SetCookies(cookieURL, []*http.Cookie{cookie})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return jar, nil
+}
+
+func parseCookieLine(line string) (*http.Cookie, *url.URL, bool, error) {
+	line = strings.TrimRight(line, "\r")
+	httpOnly := false
+	if strings.HasPrefix(line, httpOnlyPrefix) {
+		httpOnly = true
+		line = strings.TrimPrefix(line, httpOnlyPrefix)
+	}
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+		return nil, nil, false, nil
+	}
+
+	fields := strings.Split(line, "\t")
+	if len(fields) != 7 {
+		return nil, nil, false, fmt.Errorf("expected 7 tab-separated fields, got %d", len(fields))
+	}
+
+	domain := fields[0]
+	includeSubdomains := strings.EqualFold(fields[1], "TRUE")
+	secure := strings.EqualFold(fields[3], "TRUE")
+	expires, err := strconv.ParseInt(fields[4], 10, 64)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("invalid expiry %q", fields[4])
+	}
+
+	host := strings.TrimPrefix(domain, ".")
+	if host == "" {
+		return nil, nil, false, fmt.Errorf("empty cookie domain")
+	}
+
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+
+	cookie := &http.Cookie{
+		Name:     fields[5],
+		Value:    fields[6],
+		Path:     fields[2],
+		Secure:   secure,
+		HttpOnly: httpOnly,
+	}
+	if includeSubdomains {
+		cookie.Domain = host
+	}
+	if expires > 0 {
+		cookie.Expires = time.Unix(expires, 0)
+	}
+
+	return cookie, &url.URL{Scheme: scheme, Host: host, Path: fields[2]}, true, nil
+}
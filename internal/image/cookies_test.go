@@ -0,0 +1,68 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadCookieFile(t *testing.T) {
+	content := "# Netscape HTTP Cookie File\n" +
+		"\n" +
+		"127.0.0.1\tFALSE\t/\tFALSE\t0\tsession\tabc123\n" +
+		"#HttpOnly_127.0.0.1\tFALSE\t/\tFALSE\t0\tauth\tsecret\n" +
+		"expired.test\tFALSE\t/\tFALSE\t1\told\tgone\n"
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write cookies: %v", err)
+	}
+
+	jar, err := LoadCookieFile(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	var gotSession, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("session"); err == nil {
+			gotSession = c.Value
+		}
+		if c, err := r.Cookie("auth"); err == nil {
+			gotAuth = c.Value
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("jpg"))
+	}))
+	defer server.Close()
+
+	client := server.Client()
+	client.Jar = jar
+	proc := NewProcessor(client, bytes.NewReader([]byte{1, 2, 3}))
+	if _, err := proc.Process(context.Background(), server.URL+"/pic.jpg", t.TempDir(), false); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+
+	if gotSession != "abc123" || gotAuth != "secret" {
+		t.Fatalf("unexpected cookies: session=%q auth=%q", gotSession, gotAuth)
+	}
+
+	expired, _ := url.Parse("http://expired.test/")
+	if cookies := jar.Cookies(expired); len(cookies) != 0 {
+		t.Fatalf("expected expired cookie to be dropped, got %v", cookies)
+	}
+}
+
+func TestLoadCookieFileInvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(path, []byte("example.com\tTRUE\t/\n"), 0o600); err != nil {
+		t.Fatalf("write cookies: %v", err)
+	}
+	if _, err := LoadCookieFile(path); err == nil {
+		t.Fatal("expected error for malformed line")
+	}
+}
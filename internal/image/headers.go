@@ -0,0 +1,61 @@
+package image
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HeaderRules holds the headers sent with every download plus extra
+// headers for specific hosts. A host key matches the host itself and any
+// of its subdomains; host headers override default ones.
+type HeaderRules struct {
+	Default http.Header
+	Hosts   map[string]http.Header
+}
+
+func (r HeaderRules) Apply(req *http.Request) {
+	for key, values := range r.Default {
+		req.Header[key] = append([]string(nil), values...)
+	}
+
+	host := strings.ToLower(req.URL.Hostname())
+	for pattern, header := range r.Hosts {
+		if !hostMatches(host, pattern) {
+			continue
+		}
+		for key, values := range header {
+			req.Header[key] = append([]string(nil), values...)
+		}
+	}
+}
+
+// applyRedirect re-applies r to req, a redirect of a request r was applied
+// to. Host headers are dropped first, so they only reach hosts they are
+// meant for.
+func (r HeaderRules) applyRedirect(req *http.Request) {
+	for _, header := range r.Hosts {
+		for key := range header {
+			req.Header.Del(key)
+		}
+	}
+	r.Apply(req)
+}
+
+func hostMatches(host, pattern string) bool {
+	pattern = strings.ToLower(strings.TrimPrefix(pattern, "*."))
+	if pattern == "" {
+		return false
+	}
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+// ParseHeader parses a "Key: Value" header specification.
+func ParseHeader(spec string) (string, string, error) {
+	key, value, ok := strings.Cut(spec, ":")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("invalid header %q, expected Key:Value", spec)
+	}
+	return http.CanonicalHeaderKey(key), strings.TrimSpace(value), nil
+}
@@ -0,0 +1,112 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	key, value, err := ParseHeader("x-api-key: secret:value")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if key != "X-Api-Key" || value != "secret:value" {
+		t.Fatalf("unexpected header: %s=%s", key, value)
+	}
+
+	if _, _, err := ParseHeader("novalue"); err == nil {
+		t.Fatal("expected error for header without colon")
+	}
+}
+
+func TestHostMatches(t *testing.T) {
+	if !hostMatches("img.example.com", "example.com") {
+		t.Fatal("expected subdomain to match")
+	}
+	if !hostMatches("example.com", "*.example.com") {
+		t.Fatal("expected wildcard to match apex")
+	}
+	if hostMatches("badexample.com", "example.com") {
+		t.Fatal("expected unrelated host not to match")
+	}
+}
+
+func TestDownloadSendsHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	rules := HeaderRules{
+		Default: http.Header{
+			"User-Agent": []string{"wugo-test"},
+			"Referer":    []string{"https://default.test/"},
+		},
+		Hosts: map[string]http.Header{
+			"127.0.0.1": {"Referer": []string{"https://gallery.test/"}, "X-Token": []string{"abc"}},
+			"other.com": {"X-Other": []string{"nope"}},
+		},
+	}
+	proc := NewProcessor(server.Client(), bytes.NewReader([]byte{1, 2, 3}), WithHeaders(rules))
+
+	if _, err := proc.Process(context.Background(), server.URL+"/pic.png", t.TempDir(), false); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+
+	if got.Get("User-Agent") != "wugo-test" {
+		t.Fatalf("unexpected user agent: %q", got.Get("User-Agent"))
+	}
+	if got.Get("Referer") != "https://gallery.test/" {
+		t.Fatalf("expected host referer, got %q", got.Get("Referer"))
+	}
+	if got.Get("X-Token") != "abc" {
+		t.Fatalf("missing host header: %v", got)
+	}
+	if got.Get("X-Other") != "" {
+		t.Fatalf("unexpected header from other host: %v", got)
+	}
+}
+
+func TestRedirectDropsOtherHostHeaders(t *testing.T) {
+	var got http.Header
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer target.Close()
+	targetURL := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "abc" {
+			t.Errorf("origin did not get its header: %v", r.Header)
+		}
+		http.Redirect(w, r, targetURL+"/pic.png", http.StatusFound)
+	}))
+	defer origin.Close()
+
+	rules := HeaderRules{
+		Default: http.Header{"User-Agent": []string{"wugo-test"}},
+		Hosts: map[string]http.Header{
+			"127.0.0.1": {"X-Token": []string{"abc"}},
+			"localhost": {"X-Cdn": []string{"cdn"}},
+		},
+	}
+	proc := NewProcessor(origin.Client(), bytes.NewReader([]byte{1, 2, 3}), WithHeaders(rules))
+
+	if _, err := proc.Process(context.Background(), origin.URL+"/pic.png", t.TempDir(), false); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if got.Get("X-Token") != "" {
+		t.Fatalf("origin header leaked to the redirect target: %v", got)
+	}
+	if got.Get("X-Cdn") != "cdn" || got.Get("User-Agent") != "wugo-test" {
+		t.Fatalf("expected the target's and default headers, got %v", got)
+	}
+}
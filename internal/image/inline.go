@@ -0,0 +1,102 @@
+package image
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	stdinInput = "-"
+	sniffLen   = 512
+)
+
+func isDataURL(input string) bool {
+	return len(input) > 5 && strings.EqualFold(input[:5], "data:")
+}
+
+// readStdin stores image bytes piped to wugo.
+func (p *Processor) readStdin(saveDir string) (string, error) {
+	return p.storeStream(p.stdinReader(), "stdin", "", saveDir)
+}
+
+// readDataURL stores the payload of an RFC 2397 data: URL.
+func (p *Processor) readDataURL(input, saveDir string) (string, error) {
+	header, payload, ok := strings.Cut(input[5:], ",")
+	if !ok {
+		return "", errors.New("invalid data URL: missing comma")
+	}
+
+	params := strings.Split(header, ";")
+	declared := normalizeMediaType(params[0])
+	isBase64 := false
+	for _, param := range params[1:] {
+		if strings.EqualFold(param, "base64") {
+			isBase64 = true
+		}
+	}
+
+	var body io.Reader
+	if isBase64 {
+		payload = strings.Map(dropSpace, payload)
+		body = base64.NewDecoder(base64.StdEncoding, strings.NewReader(payload))
+	} else {
+		decoded, err := url.PathUnescape(payload)
+		if err != nil {
+			return "", fmt.Errorf("decode data URL: %w", err)
+		}
+		body = strings.NewReader(decoded)
+	}
+
+	return p.storeStream(body, "data", declared, saveDir)
+}
+
+// storeStream sniffs the content type of src, rejects non-images and
+// writes it to a generated file in saveDir.
+func (p *Processor) storeStream(src io.Reader, base, declaredType, saveDir string) (string, error) {
+	buffered := bufio.NewReaderSize(src, sniffLen)
+	head, err := buffered.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read image data: %w", err)
+	}
+	if len(head) == 0 {
+		return "", errors.New("empty image data")
+	}
+
+	mediaType := sniffMediaType(head, declaredType)
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("unexpected content type: %s", mediaType)
+	}
+
+	return p.writeUnique(saveDir, base, extensionFromContentType(mediaType), buffered)
+}
+
+// sniffMediaType prefers the detected type and falls back to the declared
+// one for formats http.DetectContentType cannot recognise, such as SVG.
+func sniffMediaType(head []byte, declaredType string) string {
+	detected := normalizeMediaType(http.DetectContentType(head))
+	if strings.HasPrefix(detected, "image/") {
+		return detected
+	}
+	if declaredType == "image/svg+xml" || (declaredType == "" && looksLikeSVG(head)) {
+		return "image/svg+xml"
+	}
+	return detected
+}
+
+func looksLikeSVG(head []byte) bool {
+	return strings.Contains(strings.ToLower(string(head)), "<svg")
+}
+
+func dropSpace(r rune) rune {
+	switch r {
+	case ' ', '\t', '\n', '\r':
+		return -1
+	}
+	return r
+}
@@ -0,0 +1,102 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestProcessStdin(t *testing.T) {
+	saveDir := t.TempDir()
+	proc := NewProcessor(nil, bytes.NewReader([]byte{0x01, 0x02, 0x03}), WithStdin(bytes.NewReader(pngHeader)))
+
+	got, err := proc.Process(context.Background(), "-", saveDir, false)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+
+	if filepath.Base(got) != "stdin_010203.png" {
+		t.Fatalf("unexpected file name: %s", got)
+	}
+	data, err := os.ReadFile(got)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	if !bytes.Equal(data, pngHeader) {
+		t.Fatalf("unexpected contents: %q", data)
+	}
+}
+
+func TestProcessStdinRejectsNonImage(t *testing.T) {
+	saveDir := t.TempDir()
+	proc := NewProcessor(nil, nil, WithStdin(strings.NewReader("hello world")))
+
+	if _, err := proc.Process(context.Background(), "-", saveDir, false); err == nil {
+		t.Fatal("expected error for text on stdin")
+	}
+	entries, _ := os.ReadDir(saveDir)
+	if len(entries) != 0 {
+		t.Fatalf("expected nothing stored, got %d entries", len(entries))
+	}
+}
+
+func TestProcessStdinEmpty(t *testing.T) {
+	proc := NewProcessor(nil, nil, WithStdin(strings.NewReader("")))
+	if _, err := proc.Process(context.Background(), "-", t.TempDir(), false); err == nil {
+		t.Fatal("expected error for empty stdin")
+	}
+}
+
+func TestProcessDataURL(t *testing.T) {
+	saveDir := t.TempDir()
+	proc := NewProcessor(nil, bytes.NewReader([]byte{0xaa, 0xbb, 0xcc}))
+	input := "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngHeader)
+
+	got, err := proc.Process(context.Background(), input, saveDir, false)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if filepath.Base(got) != "data_aabbcc.png" {
+		t.Fatalf("unexpected file name: %s", got)
+	}
+	data, err := os.ReadFile(got)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	if !bytes.Equal(data, pngHeader) {
+		t.Fatalf("unexpected contents: %q", data)
+	}
+}
+
+func TestProcessDataURLSVG(t *testing.T) {
+	proc := NewProcessor(nil, bytes.NewReader([]byte{0x01, 0x02, 0x03}))
+	input := "data:image/svg+xml,%3Csvg%20xmlns%3D%22http%3A%2F%2Fwww.w3.org%2F2000%2Fsvg%22%2F%3E"
+
+	got, err := proc.Process(context.Background(), input, t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if !strings.HasSuffix(got, ".svg") {
+		t.Fatalf("expected .svg extension, got %s", got)
+	}
+}
+
+func TestProcessDataURLInvalid(t *testing.T) {
+	proc := NewProcessor(nil, nil)
+	cases := []string{
+		"data:image/png;base64",
+		"data:image/png;base64,!!!!",
+		"data:text/plain,hello",
+	}
+	for _, input := range cases {
+		if _, err := proc.Process(context.Background(), input, t.TempDir(), false); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}
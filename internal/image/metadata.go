@@ -0,0 +1,55 @@
+package image
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+const metadataSuffix = ".json"
+
+// Metadata describes where a stored image came from. It is written as a
+// sidecar next to the image, e.g. photo_a1b2c3.jpg.json.
+type Metadata struct {
+	Source      string   `json:"source"`
+	PageURL     string   `json:"page_url,omitempty"`
+	ImageURL    string   `json:"image_url,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	Attribution string   `json:"attribution,omitempty"`
+	Copyright   string   `json:"copyright,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// MetadataPath returns the sidecar path for imagePath.
+func MetadataPath(imagePath string) string {
+	return imagePath + metadataSuffix
+}
+
+func WriteMetadata(imagePath string, meta Metadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(MetadataPath(imagePath), data, 0o644)
+}
+
+// ReadMetadata loads the sidecar of imagePath. ok is false when the image
+// has none.
+func ReadMetadata(imagePath string) (Metadata, bool, error) {
+	var meta Metadata
+
+	data, err := os.ReadFile(MetadataPath(imagePath))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return meta, false, nil
+		}
+		return meta, false, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, false, err
+	}
+
+	return meta, true, nil
+}
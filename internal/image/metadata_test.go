@@ -0,0 +1,30 @@
+package image
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMetadataRoundTrip(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "photo_abcdef.jpg")
+
+	if _, ok, err := ReadMetadata(imagePath); ok || err != nil {
+		t.Fatalf("expected no metadata, got ok=%v err=%v", ok, err)
+	}
+
+	meta := Metadata{Source: "wallhaven", PageURL: "https://wallhaven.cc/w/x", Tags: []string{"nature"}}
+	if err := WriteMetadata(imagePath, meta); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	got, ok, err := ReadMetadata(imagePath)
+	if err != nil || !ok {
+		t.Fatalf("read: ok=%v err=%v", ok, err)
+	}
+	if got.Source != meta.Source || got.PageURL != meta.PageURL || len(got.Tags) != 1 {
+		t.Fatalf("unexpected metadata: %+v", got)
+	}
+	if MetadataPath(imagePath) != imagePath+".json" {
+		t.Fatalf("unexpected sidecar path: %s", MetadataPath(imagePath))
+	}
+}
@@ -14,27 +14,91 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
-	"time"
+
+	"wugo/internal/httpclient"
 )
 
 const (
 	defaultSuffixLength = 6
-	defaultTimeout      = 30 * time.Second
+	defaultTimeout      = httpclient.DefaultTimeout
 )
 
 type Processor struct {
-	client *http.Client
-	rand   io.Reader
+	client   *http.Client
+	rand     io.Reader
+	headers  HeaderRules
+	refresh  bool
+	stdin    io.Reader
+	sources  *Registry
+	recorder Recorder
+}
+
+// Recorder is told about every image Process returns, e.g. to keep a
+// library index of the save directory up to date.
+type Recorder interface {
+	Record(saveDir, path, sourceURL string) error
+}
+
+// Releaser is an optional part of a Recorder. Release is told about a
+// cached file that a newer download of the same URL superseded, and removes
+// it unless it is still in use.
+type Releaser interface {
+	Release(saveDir, path string) error
+}
+
+// Option configures optional Processor behaviour.
+type Option func(*Processor)
+
+// WithHeaders sets the headers sent with every download.
+func WithHeaders(rules HeaderRules) Option {
+	return func(p *Processor) {
+		p.headers = rules
+	}
 }
 
-func NewProcessor(client *http.Client, randReader io.Reader) *Processor {
+// WithRefresh makes downloads ignore cached copies and always fetch the
+// full response.
+func WithRefresh(refresh bool) Option {
+	return func(p *Processor) {
+		p.refresh = refresh
+	}
+}
+
+// WithStdin sets the reader used for the "-" input. Defaults to os.Stdin.
+func WithStdin(r io.Reader) Option {
+	return func(p *Processor) {
+		p.stdin = r
+	}
+}
+
+// WithRecorder reports stored images to r.
+func WithRecorder(r Recorder) Option {
+	return func(p *Processor) {
+		p.recorder = r
+	}
+}
+
+func NewProcessor(client *http.Client, randReader io.Reader, opts ...Option) *Processor {
 	if client == nil {
 		client = &http.Client{Timeout: defaultTimeout}
 	}
 	if randReader == nil {
 		randReader = rand.Reader
 	}
-	return &Processor{client: client, rand: randReader}
+	p := &Processor{rand: randReader}
+	p.client = p.withRedirects(client)
+	p.sources = NewRegistry(localSource{p}, stdinSource{p}, dataSource{p}, fileURLSource{p}, httpSource{p})
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Register adds a source for a new kind of input. Registered sources are
+// consulted after the built-in stdin, data:, file:// and http(s) sources and
+// before the local path fallback.
+func (p *Processor) Register(src Source) {
+	p.sources.Register(src)
 }
 
 func (p *Processor) Process(ctx context.Context, input, saveDir string, noMove bool) (string, error) {
@@ -42,21 +106,80 @@ func (p *Processor) Process(ctx context.Context, input, saveDir string, noMove b
 		return "", errors.New("empty input")
 	}
 
-	if filePath, ok, err := fileURLPath(input); ok {
-		if err != nil {
-			return "", err
-		}
-		input = filePath
+	src, err := p.sources.Resolve(input)
+	if err != nil {
+		return "", err
 	}
 
-	if isRemoteURL(input) {
-		return p.download(ctx, input, saveDir)
+	localPath, err := src.Fetch(WithNoMove(ctx, noMove), input, saveDir)
+	if err != nil {
+		return "", err
 	}
 
-	return p.handleLocal(input, saveDir, noMove)
+	if p.recorder != nil {
+		if err := p.recorder.Record(saveDir, localPath, recordedSource(input, localPath)); err != nil {
+			return "", fmt.Errorf("update library: %w", err)
+		}
+	}
+
+	return localPath, nil
+}
+
+// recordedSource describes where localPath came from: the image URL from
+// its metadata, the input URL, or the original local path.
+func recordedSource(input, localPath string) string {
+	if meta, ok, err := ReadMetadata(localPath); err == nil && ok && meta.ImageURL != "" {
+		return meta.ImageURL
+	}
+	switch {
+	case input == stdinInput:
+		return "stdin"
+	case isDataURL(input):
+		mediaType, _, _ := strings.Cut(input, ",")
+		return mediaType
+	}
+	if u, err := url.Parse(input); err == nil && len(u.Scheme) > 1 {
+		return input
+	}
+	if abs, err := filepath.Abs(input); err == nil {
+		return abs
+	}
+	return input
 }
 
 func (p *Processor) handleLocal(filePath, saveDir string, noMove bool) (string, error) {
+	absPath, err := localFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	if noMove {
+		return absPath, nil
+	}
+
+	return p.place(absPath, saveDir, TransferMove)
+}
+
+// Transfer says how Import brings a local file into the save directory.
+type Transfer int
+
+const (
+	TransferMove Transfer = iota
+	TransferCopy
+	TransferLink
+)
+
+// Import moves, copies or symlinks the local file at filePath into saveDir
+// under a unique name, the way Process stores local inputs.
+func (p *Processor) Import(filePath, saveDir string, t Transfer) (string, error) {
+	absPath, err := localFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return p.place(absPath, saveDir, t)
+}
+
+func localFile(filePath string) (string, error) {
 	absPath, err := filepath.Abs(filepath.Clean(filePath))
 	if err != nil {
 		return "", fmt.Errorf("absolute path: %w", err)
@@ -70,10 +193,10 @@ func (p *Processor) handleLocal(filePath, saveDir string, noMove bool) (string,
 		return "", fmt.Errorf("path is a directory: %s", absPath)
 	}
 
-	if noMove {
-		return absPath, nil
-	}
+	return absPath, nil
+}
 
+func (p *Processor) place(absPath, saveDir string, t Transfer) (string, error) {
 	fileName := filepath.Base(absPath)
 	base := strings.TrimSuffix(fileName, filepath.Ext(fileName))
 	if base == "" {
@@ -84,30 +207,88 @@ func (p *Processor) handleLocal(filePath, saveDir string, noMove bool) (string,
 	newFileName := fmt.Sprintf("%s_%s%s", base, suffix, ext)
 	destPath := filepath.Join(saveDir, newFileName)
 
-	if err := os.Rename(absPath, destPath); err != nil {
+	switch t {
+	case TransferCopy:
 		if err := copyFile(absPath, destPath); err != nil {
 			return "", fmt.Errorf("copy file: %w", err)
 		}
-		if err := os.Remove(absPath); err != nil {
-			return "", fmt.Errorf("remove original: %w", err)
+	case TransferLink:
+		if err := os.Symlink(absPath, destPath); err != nil {
+			return "", fmt.Errorf("link file: %w", err)
+		}
+	default:
+		if err := os.Rename(absPath, destPath); err != nil {
+			if err := copyFile(absPath, destPath); err != nil {
+				return "", fmt.Errorf("copy file: %w", err)
+			}
+			if err := os.Remove(absPath); err != nil {
+				return "", fmt.Errorf("remove original: %w", err)
+			}
 		}
 	}
 
 	return filepath.Abs(destPath)
 }
 
+// Get performs a GET request through the processor's client with its
+// header rules applied. Remote sources use it to query provider APIs.
+func (p *Processor) Get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return p.Do(req)
+}
+
+// Do sends a prepared request, e.g. a signed one, through the processor's
+// client. Header rules only add headers the request does not set itself.
+func (p *Processor) Do(req *http.Request) (*http.Response, error) {
+	preset := req.Header.Clone()
+	p.headers.Apply(req)
+	for key, values := range preset {
+		req.Header[key] = values
+	}
+
+	return p.httpClient().Do(req)
+}
+
+// Download stores imageURL in saveDir through the regular HTTP path, with
+// caching and content type checks.
+func (p *Processor) Download(ctx context.Context, imageURL, saveDir string) (string, error) {
+	return p.download(ctx, imageURL, saveDir)
+}
+
 func (p *Processor) download(ctx context.Context, imageURL, saveDir string) (string, error) {
+	cache, err := loadURLCache(saveDir)
+	if err != nil {
+		return "", fmt.Errorf("load cache: %w", err)
+	}
+	cachedPath, entry, cached := cache.lookup(imageURL)
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
 	if err != nil {
 		return "", err
 	}
+	p.headers.Apply(req)
+	if cached && !p.refresh {
+		entry.applyConditional(req)
+	}
 
 	resp, err := p.httpClient().Do(req)
 	if err != nil {
+		if errors.Is(err, httpclient.ErrOffline) {
+			if cached {
+				return filepath.Abs(cachedPath)
+			}
+			return "", fmt.Errorf("%w: %s is not cached", httpclient.ErrOffline, imageURL)
+		}
 		return "", err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && cached {
+		return filepath.Abs(cachedPath)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("bad status: %s", resp.Status)
 	}
@@ -128,6 +309,51 @@ func (p *Processor) download(ctx context.Context, imageURL, saveDir string) (str
 		ext = extensionFromContentType(mediaType)
 	}
 
+	localPath, err := p.writeUnique(saveDir, base, ext, resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if err := cache.store(imageURL, localPath, cacheEntryFromResponse(resp)); err != nil {
+		_ = os.Remove(localPath)
+		return "", fmt.Errorf("update cache: %w", err)
+	}
+
+	// A new version gets its own name, so a wallpaper showing the old one
+	// notices the change and the old bytes stay around for as long as
+	// they are in use. Failing to clean up does not fail the download.
+	if releaser, ok := p.recorder.(Releaser); ok && cached {
+		_ = releaser.Release(saveDir, cachedPath)
+	}
+
+	return localPath, nil
+}
+
+// Store copies src into saveDir under a unique name derived from name, the
+// way downloads are stored. Sources that read from other protocols use it.
+func (p *Processor) Store(src io.Reader, name, saveDir string) (string, error) {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	if base == "" {
+		base = "wallpaper"
+	}
+	ext := filepath.Ext(name)
+	if ext == "" {
+		return p.storeStream(src, base, "", saveDir)
+	}
+	return p.writeUnique(saveDir, base, ext, src)
+}
+
+// IsImageFile reports whether name has a common image file extension.
+func IsImageFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jpg", ".jpeg", ".png", ".webp", ".gif", ".bmp", ".svg":
+		return true
+	}
+	return false
+}
+
+// writeUnique copies src to "<base>_<suffix><ext>" in saveDir and returns
+// the absolute path of the new file.
+func (p *Processor) writeUnique(saveDir, base, ext string, src io.Reader) (string, error) {
 	suffix := p.uniqueSuffix(defaultSuffixLength)
 	finalName := fmt.Sprintf("%s_%s%s", base, suffix, ext)
 	localPath := filepath.Join(saveDir, finalName)
@@ -137,7 +363,7 @@ func (p *Processor) download(ctx context.Context, imageURL, saveDir string) (str
 		return "", err
 	}
 
-	copyErr := copyToFile(out, resp.Body)
+	copyErr := copyToFile(out, src)
 	if copyErr != nil {
 		_ = os.Remove(localPath)
 		return "", copyErr
@@ -272,6 +498,34 @@ func (p *Processor) randReader() io.Reader {
 	return rand.Reader
 }
 
+func (p *Processor) stdinReader() io.Reader {
+	if p.stdin != nil {
+		return p.stdin
+	}
+	return os.Stdin
+}
+
+// maxRedirects is how many redirects a request follows, as in net/http.
+const maxRedirects = 10
+
+// withRedirects returns a copy of client that re-applies the header rules
+// on every redirect, before any redirect policy client already has.
+func (p *Processor) withRedirects(client *http.Client) *http.Client {
+	c := *client
+	next := client.CheckRedirect
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		p.headers.applyRedirect(req)
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
+	return &c
+}
+
 func (p *Processor) httpClient() *http.Client {
 	if p.client != nil {
 		return p.client
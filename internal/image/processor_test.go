@@ -123,6 +123,63 @@ func TestProcessLocalMove(t *testing.T) {
 	}
 }
 
+func TestImportCopyAndLink(t *testing.T) {
+	sourceDir := t.TempDir()
+	saveDir := t.TempDir()
+	filePath := filepath.Join(sourceDir, "photo.jpg")
+	if err := os.WriteFile(filePath, []byte("data"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	proc := NewProcessor(nil, nil)
+	copied, err := proc.Import(filePath, saveDir, TransferCopy)
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	linked, err := proc.Import(filePath, saveDir, TransferLink)
+	if err != nil {
+		t.Fatalf("link: %v", err)
+	}
+
+	if _, err := os.Stat(filePath); err != nil {
+		t.Fatalf("expected original kept: %v", err)
+	}
+	if info, err := os.Lstat(copied); err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("expected regular copy: %v", err)
+	}
+	if target, err := os.Readlink(linked); err != nil || target != filePath {
+		t.Fatalf("expected link to %s, got %s (%v)", filePath, target, err)
+	}
+}
+
+type fakeRecorder struct {
+	saveDir, path, source string
+}
+
+func (f *fakeRecorder) Record(saveDir, path, sourceURL string) error {
+	f.saveDir, f.path, f.source = saveDir, path, sourceURL
+	return nil
+}
+
+func TestProcessRecordsStoredImage(t *testing.T) {
+	sourceDir := t.TempDir()
+	saveDir := t.TempDir()
+	filePath := filepath.Join(sourceDir, "photo.jpg")
+	if err := os.WriteFile(filePath, []byte("data"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	recorder := &fakeRecorder{}
+	proc := NewProcessor(nil, bytes.NewReader([]byte{0x10, 0x20, 0x30}), WithRecorder(recorder))
+	got, err := proc.Process(context.Background(), filePath, saveDir, false)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if recorder.saveDir != saveDir || recorder.path != got || recorder.source != filePath {
+		t.Fatalf("unexpected record: %+v", recorder)
+	}
+}
+
 func TestDownloadImage(t *testing.T) {
 	ctx := context.Background()
 
@@ -0,0 +1,127 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Source resolves one kind of input (a scheme, a provider, a local path)
+// to an image file. Fetch stores the image in dst, the save directory, and
+// returns its absolute path.
+type Source interface {
+	Match(input string) bool
+	Fetch(ctx context.Context, input, dst string) (string, error)
+}
+
+// FetchFunc adapts a function to the Fetch half of Source.
+type FetchFunc func(ctx context.Context, input, dst string) (string, error)
+
+type prefixSource struct {
+	prefix string
+	fetch  FetchFunc
+}
+
+// PrefixSource returns a Source matching inputs that start with prefix,
+// such as "s3://" or "wallhaven:". Matching is case-insensitive.
+func PrefixSource(prefix string, fetch FetchFunc) Source {
+	return &prefixSource{prefix: prefix, fetch: fetch}
+}
+
+func (s *prefixSource) Match(input string) bool {
+	return len(input) >= len(s.prefix) && strings.EqualFold(input[:len(s.prefix)], s.prefix)
+}
+
+func (s *prefixSource) Fetch(ctx context.Context, input, dst string) (string, error) {
+	return s.fetch(ctx, input, dst)
+}
+
+// Registry keeps sources in the order they are consulted. The fallback is
+// used when no registered source matches.
+type Registry struct {
+	sources  []Source
+	fallback Source
+}
+
+func NewRegistry(fallback Source, sources ...Source) *Registry {
+	return &Registry{sources: sources, fallback: fallback}
+}
+
+// Register adds src to the registry. Sources are consulted in registration
+// order; the first match wins.
+func (r *Registry) Register(src Source) {
+	r.sources = append(r.sources, src)
+}
+
+// Resolve returns the source responsible for input.
+func (r *Registry) Resolve(input string) (Source, error) {
+	for _, src := range r.sources {
+		if src.Match(input) {
+			return src, nil
+		}
+	}
+	if r.fallback != nil && r.fallback.Match(input) {
+		return r.fallback, nil
+	}
+	return nil, fmt.Errorf("no source for input: %s", input)
+}
+
+type noMoveKey struct{}
+
+// WithNoMove records in ctx that local files must be used in place.
+func WithNoMove(ctx context.Context, noMove bool) context.Context {
+	return context.WithValue(ctx, noMoveKey{}, noMove)
+}
+
+// NoMove reports whether ctx asks for local files to be used in place.
+func NoMove(ctx context.Context) bool {
+	noMove, _ := ctx.Value(noMoveKey{}).(bool)
+	return noMove
+}
+
+type stdinSource struct{ p *Processor }
+
+func (s stdinSource) Match(input string) bool { return input == stdinInput }
+
+func (s stdinSource) Fetch(_ context.Context, _, dst string) (string, error) {
+	return s.p.readStdin(dst)
+}
+
+type dataSource struct{ p *Processor }
+
+func (s dataSource) Match(input string) bool { return isDataURL(input) }
+
+func (s dataSource) Fetch(_ context.Context, input, dst string) (string, error) {
+	return s.p.readDataURL(input, dst)
+}
+
+type fileURLSource struct{ p *Processor }
+
+func (s fileURLSource) Match(input string) bool {
+	_, ok, _ := fileURLPath(input)
+	return ok
+}
+
+func (s fileURLSource) Fetch(ctx context.Context, input, dst string) (string, error) {
+	filePath, _, err := fileURLPath(input)
+	if err != nil {
+		return "", err
+	}
+	return s.p.handleLocal(filePath, dst, NoMove(ctx))
+}
+
+type httpSource struct{ p *Processor }
+
+func (s httpSource) Match(input string) bool { return isRemoteURL(input) }
+
+func (s httpSource) Fetch(ctx context.Context, input, dst string) (string, error) {
+	return s.p.download(ctx, input, dst)
+}
+
+type localSource struct{ p *Processor }
+
+func (s localSource) Match(string) bool { return true }
+
+func (s localSource) Fetch(ctx context.Context, input, dst string) (string, error) {
+	return s.p.handleLocal(input, dst, NoMove(ctx))
+}
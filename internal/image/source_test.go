@@ -0,0 +1,86 @@
+package image
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrefixSourceMatch(t *testing.T) {
+	src := PrefixSource("s3://", nil)
+	if !src.Match("S3://bucket/key.jpg") {
+		t.Fatal("expected case-insensitive prefix match")
+	}
+	if src.Match("s3:/bucket") || src.Match("s3") {
+		t.Fatal("expected partial prefix not to match")
+	}
+}
+
+func TestRegistryOrder(t *testing.T) {
+	first := PrefixSource("x:", nil)
+	second := PrefixSource("x:", nil)
+	fallback := PrefixSource("", nil)
+	reg := NewRegistry(fallback, first)
+	reg.Register(second)
+
+	got, err := reg.Resolve("x:1")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if got != first {
+		t.Fatal("expected first registered source to win")
+	}
+
+	got, err = reg.Resolve("other")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if got != fallback {
+		t.Fatal("expected fallback source")
+	}
+
+	if _, err := NewRegistry(nil).Resolve("other"); err == nil {
+		t.Fatal("expected error without fallback")
+	}
+}
+
+func TestProcessorCustomSource(t *testing.T) {
+	var gotInput, gotDst string
+	var gotNoMove bool
+	proc := NewProcessor(nil, nil)
+	proc.Register(PrefixSource("wallhaven:", func(ctx context.Context, input, dst string) (string, error) {
+		gotInput = input
+		gotDst = dst
+		gotNoMove = NoMove(ctx)
+		return filepath.Join(dst, "picked.jpg"), nil
+	}))
+
+	saveDir := t.TempDir()
+	got, err := proc.Process(context.Background(), "wallhaven:nature", saveDir, true)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if got != filepath.Join(saveDir, "picked.jpg") {
+		t.Fatalf("unexpected path: %s", got)
+	}
+	if gotInput != "wallhaven:nature" || gotDst != saveDir || !gotNoMove {
+		t.Fatalf("unexpected fetch call: %q %q %v", gotInput, gotDst, gotNoMove)
+	}
+}
+
+func TestProcessorBuiltinsBeforeCustomSources(t *testing.T) {
+	proc := NewProcessor(nil, nil)
+	called := false
+	proc.Register(PrefixSource("file:", func(context.Context, string, string) (string, error) {
+		called = true
+		return "", nil
+	}))
+
+	dir := t.TempDir()
+	if _, err := proc.Process(context.Background(), "file://"+filepath.ToSlash(filepath.Join(dir, "missing.png")), dir, true); err == nil {
+		t.Fatal("expected built-in file source to report missing file")
+	}
+	if called {
+		t.Fatal("expected built-in file source to take precedence")
+	}
+}
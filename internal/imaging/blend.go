@@ -0,0 +1,48 @@
+package imaging
+
+import (
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/image/draw"
+)
+
+// Blend crossfades from a to b: t is 0 for a and 1 for b. b is scaled to
+// the size of a.
+func Blend(a, b image.Image, t float64) *image.RGBA {
+	bounds := image.Rect(0, 0, a.Bounds().Dx(), a.Bounds().Dy())
+	from := image.NewRGBA(bounds)
+	draw.Draw(from, bounds, a, a.Bounds().Min, draw.Src)
+	to := image.NewRGBA(bounds)
+	draw.ApproxBiLinear.Scale(to, bounds, b, b.Bounds(), draw.Src, nil)
+
+	t = max(0, min(1, t))
+	out := image.NewRGBA(bounds)
+	for i := range out.Pix {
+		out.Pix[i] = uint8(float64(from.Pix[i])*(1-t) + float64(to.Pix[i])*t + 0.5)
+	}
+	return out
+}
+
+// SaveJPEG writes img to path, replacing it atomically.
+func SaveJPEG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".blend-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := jpeg.Encode(tmp, img, &jpeg.Options{Quality: 92}); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,39 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlend(t *testing.T) {
+	black := image.NewUniform(color.Black)
+	white := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range white.Pix {
+		white.Pix[i] = 255
+	}
+	a := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := range 8 {
+		for x := range 8 {
+			a.Set(x, y, black.C)
+		}
+	}
+
+	mid := Blend(a, white, 0.25)
+	if mid.Bounds().Dx() != 8 {
+		t.Fatalf("expected result sized like a, got %v", mid.Bounds())
+	}
+	if r, _, _, _ := mid.At(3, 3).RGBA(); r>>8 < 62 || r>>8 > 66 {
+		t.Fatalf("unexpected blended value: %d", r>>8)
+	}
+
+	path := filepath.Join(t.TempDir(), "blend", "mid.jpg")
+	if err := SaveJPEG(path, mid); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	info, err := ReadInfo(path)
+	if err != nil || info.Format != "jpeg" || info.Width != 8 {
+		t.Fatalf("unexpected saved image: %+v %v", info, err)
+	}
+}
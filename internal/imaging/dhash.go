@@ -0,0 +1,83 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+)
+
+// maxSamples bounds how many pixels per axis DHash reads, so hashing a
+// large photo stays cheap.
+const maxSamples = 512
+
+// DHash computes the 64-bit difference hash of img: the image is reduced
+// to 9x8 grey cells and each bit records whether a cell is brighter than
+// its right neighbour. Rescaled or recompressed copies of an image have
+// hashes a few bits apart.
+func DHash(img image.Image) uint64 {
+	const cols, rows = 9, 8
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return 0
+	}
+	stepX, stepY := max(1, w/maxSamples), max(1, h/maxSamples)
+
+	var sum [rows][cols]float64
+	var count [rows][cols]int
+	for y := 0; y < h; y += stepY {
+		cy := y * rows / h
+		for x := 0; x < w; x += stepX {
+			cx := x * cols / w
+			r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			sum[cy][cx] += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+			count[cy][cx]++
+		}
+	}
+
+	var cells [rows][cols]float64
+	for y := range rows {
+		for x := range cols {
+			if count[y][x] > 0 {
+				cells[y][x] = sum[y][x] / float64(count[y][x])
+			}
+		}
+	}
+
+	var hash uint64
+	for y := range rows {
+		for x := range cols - 1 {
+			hash <<= 1
+			if cells[y][x] > cells[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// HashFile decodes the image at path and returns its DHash.
+func HashFile(path string) (uint64, error) {
+	img, _, err := Decode(path)
+	if err != nil {
+		return 0, err
+	}
+	return DHash(img), nil
+}
+
+// FormatHash renders a hash as 16 hex digits.
+func FormatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// ParseHash parses a hash written by FormatHash.
+func ParseHash(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// Distance returns the number of differing bits between two hashes.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,51 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/draw"
+)
+
+func gradient(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			v := uint8((x*255/w + y*97/h) % 256)
+			if (x/(w/4)+y/(h/3))%2 == 0 {
+				v = 255 - v
+			}
+			img.Set(x, y, color.RGBA{R: v, G: v / 2, B: 255 - v, A: 255})
+		}
+	}
+	return img
+}
+
+func TestDHashNearDuplicates(t *testing.T) {
+	original := gradient(800, 600)
+
+	scaled := image.NewRGBA(image.Rect(0, 0, 200, 150))
+	draw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), original, original.Bounds(), draw.Src, nil)
+
+	other := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	for y := range 600 {
+		for x := range 800 {
+			v := uint8(y * 255 / 600)
+			other.Set(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+
+	a, b, c := DHash(original), DHash(scaled), DHash(other)
+	if d := Distance(a, b); d > 4 {
+		t.Fatalf("expected scaled copy to be close, distance %d", d)
+	}
+	if d := Distance(a, c); d < 10 {
+		t.Fatalf("expected different image to be far, distance %d", d)
+	}
+
+	parsed, err := ParseHash(FormatHash(a))
+	if err != nil || parsed != a {
+		t.Fatalf("hash round trip failed: %x %v", parsed, err)
+	}
+}
@@ -0,0 +1,86 @@
+// Package imaging decodes stored wallpapers for analysis such as reading
+// dimensions.
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	// Register decoders for the formats wugo stores.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+)
+
+// Info describes an image file without decoding its pixels.
+type Info struct {
+	Width  int
+	Height int
+	Format string
+}
+
+// ParseResolution parses "WIDTHxHEIGHT", e.g. "1920x1080". An empty value
+// is 0x0, meaning no minimum.
+func ParseResolution(value string) (int, int, error) {
+	if value == "" {
+		return 0, 0, nil
+	}
+	w, h, ok := strings.Cut(strings.ToLower(value), "x")
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if !ok || errW != nil || errH != nil || width < 0 || height < 0 {
+		return 0, 0, fmt.Errorf("invalid resolution %q, expected WIDTHxHEIGHT", value)
+	}
+	return width, height, nil
+}
+
+// ReadInfo returns the dimensions and format of the image at path. Formats
+// without a decoder, such as SVG, report only the format taken from the
+// file extension.
+func ReadInfo(path string) (Info, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Info{}, err
+	}
+	defer file.Close()
+
+	cfg, format, err := image.DecodeConfig(file)
+	if err != nil {
+		if ext := formatFromExt(path); ext != "" {
+			return Info{Format: ext}, nil
+		}
+		return Info{}, fmt.Errorf("decode %s: %w", filepath.Base(path), err)
+	}
+
+	return Info{Width: cfg.Width, Height: cfg.Height, Format: format}, nil
+}
+
+// Decode reads the full image at path.
+func Decode(path string) (image.Image, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	img, format, err := image.Decode(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("decode %s: %w", filepath.Base(path), err)
+	}
+	return img, format, nil
+}
+
+func formatFromExt(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".svg":
+		return "svg"
+	}
+	return ""
+}
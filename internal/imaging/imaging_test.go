@@ -0,0 +1,79 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePNG(t *testing.T, path string, w, h int) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+}
+
+func TestReadInfo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pic.png")
+	writePNG(t, path, 32, 18)
+
+	info, err := ReadInfo(path)
+	if err != nil {
+		t.Fatalf("read info: %v", err)
+	}
+	if info.Width != 32 || info.Height != 18 || info.Format != "png" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+
+	img, format, err := Decode(path)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if format != "png" || img.Bounds().Dx() != 32 {
+		t.Fatalf("unexpected decode result: %s %v", format, img.Bounds())
+	}
+}
+
+func TestReadInfoSVGAndGarbage(t *testing.T) {
+	dir := t.TempDir()
+	svg := filepath.Join(dir, "logo.svg")
+	if err := os.WriteFile(svg, []byte("<svg/>"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	info, err := ReadInfo(svg)
+	if err != nil || info.Format != "svg" || info.Width != 0 {
+		t.Fatalf("unexpected svg info: %+v err=%v", info, err)
+	}
+
+	bad := filepath.Join(dir, "bad.jpg")
+	if err := os.WriteFile(bad, []byte("nope"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := ReadInfo(bad); err == nil {
+		t.Fatal("expected error for undecodable file")
+	}
+}
+
+func TestParseResolution(t *testing.T) {
+	if w, h, err := ParseResolution("1920X1080"); err != nil || w != 1920 || h != 1080 {
+		t.Fatalf("unexpected result %dx%d: %v", w, h, err)
+	}
+	if w, h, err := ParseResolution(""); err != nil || w != 0 || h != 0 {
+		t.Fatalf("expected no minimum, got %dx%d: %v", w, h, err)
+	}
+	for _, value := range []string{"1920x1080abc", "1920", "x1080", "-1x5", "1920 x 1080"} {
+		if _, _, err := ParseResolution(value); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
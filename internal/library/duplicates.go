@@ -0,0 +1,135 @@
+package library
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+
+	"wugo/internal/image"
+	"wugo/internal/imaging"
+)
+
+// DefaultThreshold is the largest perceptual hash distance, in bits, at
+// which two images count as near-duplicates.
+const DefaultThreshold = 10
+
+// Similar returns the entries within threshold bits of e, closest first.
+func (ix *Index) Similar(e Entry, threshold int) []Entry {
+	hash, err := imaging.ParseHash(e.DHash)
+	if err != nil {
+		return nil
+	}
+
+	type match struct {
+		entry    Entry
+		distance int
+	}
+	var matches []match
+	for _, other := range ix.Entries {
+		if other.File == e.File {
+			continue
+		}
+		h, err := imaging.ParseHash(other.DHash)
+		if err != nil {
+			continue
+		}
+		if d := imaging.Distance(hash, h); d <= threshold {
+			matches = append(matches, match{other, d})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].distance < matches[j].distance })
+	out := make([]Entry, len(matches))
+	for i, m := range matches {
+		out[i] = m.entry
+	}
+	return out
+}
+
+// Duplicates groups entries whose perceptual hashes are within threshold
+// bits of each other, directly or through other members of the group. The
+// first entry of each group is the best copy: highest resolution, then
+// largest file, then oldest.
+func (ix *Index) Duplicates(threshold int) [][]Entry {
+	var hashed []Entry
+	var hashes []uint64
+	for _, e := range ix.Entries {
+		if h, err := imaging.ParseHash(e.DHash); err == nil {
+			hashed = append(hashed, e)
+			hashes = append(hashes, h)
+		}
+	}
+
+	parent := make([]int, len(hashed))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range hashed {
+		for j := i + 1; j < len(hashed); j++ {
+			if imaging.Distance(hashes[i], hashes[j]) <= threshold {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := make(map[int][]Entry)
+	for i, e := range hashed {
+		root := find(i)
+		groups[root] = append(groups[root], e)
+	}
+
+	var out [][]Entry
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool { return betterCopy(group[i], group[j]) })
+		out = append(out, group)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i][0].File < out[j][0].File })
+	return out
+}
+
+func betterCopy(a, b Entry) bool {
+	if pa, pb := a.Width*a.Height, b.Width*b.Height; pa != pb {
+		return pa > pb
+	}
+	if a.Size != b.Size {
+		return a.Size > b.Size
+	}
+	if !a.Added.Equal(b.Added) {
+		return a.Added.Before(b.Added)
+	}
+	return a.File < b.File
+}
+
+// MergeLabels gives dst the tags and favourite star of src as well.
+func (ix *Index) MergeLabels(dst, src Entry) {
+	from := ix.LabelsOf(src)
+	ix.AddTags(dst, from.Tags...)
+	if from.Favorite {
+		ix.SetFavorite(dst, true)
+	}
+}
+
+// Delete removes the file of e and its metadata sidecar from disk and drops
+// it from the index. Its labels stay, in case the same image returns.
+func (ix *Index) Delete(e Entry) error {
+	path := ix.Path(e)
+	for _, p := range []string{path, image.MetadataPath(path)} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	ix.Entries = slices.DeleteFunc(ix.Entries, func(other Entry) bool { return other.File == e.File })
+	return nil
+}
@@ -0,0 +1,117 @@
+package library
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	wimage "wugo/internal/image"
+)
+
+func TestDuplicatesKeepsBestCopy(t *testing.T) {
+	ix := &Index{dir: t.TempDir(), Entries: []Entry{
+		{File: "small.jpg", DHash: "ff00ff00ff00ff00", Width: 1280, Height: 720},
+		{File: "big.png", DHash: "ff00ff00ff00ff01", Width: 3840, Height: 2160},
+		{File: "mid.jpg", DHash: "ff00ff00ff00ff03", Width: 1920, Height: 1080},
+		{File: "other.png", DHash: "00ff00ff00ff00ff", Width: 3840, Height: 2160},
+		{File: "vector.svg", Format: "svg"},
+	}}
+
+	groups := ix.Duplicates(DefaultThreshold)
+	if len(groups) != 1 || len(groups[0]) != 3 {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+	if groups[0][0].File != "big.png" || groups[0][1].File != "mid.jpg" || groups[0][2].File != "small.jpg" {
+		t.Fatalf("unexpected order: %+v", groups[0])
+	}
+	if len(ix.Duplicates(0)) != 0 {
+		t.Fatal("expected no groups at threshold 0")
+	}
+
+	similar := ix.Similar(ix.Entries[0], 2)
+	if len(similar) != 2 || similar[0].File != "big.png" {
+		t.Fatalf("unexpected similar: %+v", similar)
+	}
+}
+
+func TestDeleteAndMergeLabels(t *testing.T) {
+	dir := t.TempDir()
+	keep := filepath.Join(dir, "keep.png")
+	drop := filepath.Join(dir, "drop.png")
+	writePNG(t, keep, 8, 8)
+	writePNG(t, drop, 4, 4)
+	if err := wimage.WriteMetadata(drop, wimage.Metadata{Title: "x"}); err != nil {
+		t.Fatalf("metadata: %v", err)
+	}
+
+	ix, _ := Open(dir)
+	if _, err := ix.Add(keep, "", t0); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := ix.Add(drop, "", t0); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	ix.Entries[1].Hash = "drop"
+	ix.AddTags(ix.Entries[1], "dark")
+	ix.SetFavorite(ix.Entries[1], true)
+
+	ix.MergeLabels(ix.Entries[0], ix.Entries[1])
+	if err := ix.Delete(ix.Entries[1]); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if len(ix.Entries) != 1 {
+		t.Fatalf("expected one entry left, got %+v", ix.Entries)
+	}
+	for _, p := range []string{drop, wimage.MetadataPath(drop)} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Fatalf("expected %s removed, got %v", p, err)
+		}
+	}
+	if l := ix.LabelsOf(ix.Entries[0]); !l.Favorite || !l.HasTag("dark") {
+		t.Fatalf("expected labels merged, got %+v", l)
+	}
+}
+
+func TestRecorderWarnsAboutNearDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	pattern := func(path string, size int) {
+		img := image.NewGray(image.Rect(0, 0, size, size))
+		for y := range size {
+			for x := range size {
+				if (x*4/size+y*3/size)%2 == 0 {
+					img.Set(x, y, color.Gray{Y: uint8(255 * x / size)})
+				}
+			}
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		defer file.Close()
+		if err := png.Encode(file, img); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+	}
+	pattern(filepath.Join(dir, "large.png"), 256)
+	pattern(filepath.Join(dir, "small.png"), 64)
+
+	var warnings bytes.Buffer
+	r := Recorder{Warnings: &warnings}
+	if err := r.Record(dir, filepath.Join(dir, "large.png"), ""); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if warnings.Len() != 0 {
+		t.Fatalf("unexpected warning: %s", warnings.String())
+	}
+	if err := r.Record(dir, filepath.Join(dir, "small.png"), ""); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if !strings.Contains(warnings.String(), "small.png looks like a near-duplicate of large.png") {
+		t.Fatalf("expected near-duplicate warning, got %q", warnings.String())
+	}
+}
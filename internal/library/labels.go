@@ -0,0 +1,84 @@
+package library
+
+import (
+	"slices"
+	"strings"
+)
+
+// Labels are the user's tags and favourite star for an image. They are
+// keyed by content hash so they survive renames and moves.
+type Labels struct {
+	Tags     []string `json:"tags,omitempty"`
+	Favorite bool     `json:"favorite,omitempty"`
+}
+
+func (l Labels) empty() bool {
+	return len(l.Tags) == 0 && !l.Favorite
+}
+
+// HasTag reports whether the image carries tag.
+func (l Labels) HasTag(tag string) bool {
+	return slices.Contains(l.Tags, normalizeTag(tag))
+}
+
+// LabelsOf returns the labels of e.
+func (ix *Index) LabelsOf(e Entry) Labels {
+	return ix.Labels[e.Hash]
+}
+
+// AddTags tags e, ignoring tags it already has.
+func (ix *Index) AddTags(e Entry, tags ...string) {
+	ix.updateLabels(e, func(l *Labels) {
+		for _, tag := range tags {
+			if tag = normalizeTag(tag); tag != "" && !slices.Contains(l.Tags, tag) {
+				l.Tags = append(l.Tags, tag)
+			}
+		}
+		slices.Sort(l.Tags)
+	})
+}
+
+// RemoveTags removes tags from e.
+func (ix *Index) RemoveTags(e Entry, tags ...string) {
+	ix.updateLabels(e, func(l *Labels) {
+		l.Tags = slices.DeleteFunc(l.Tags, func(t string) bool {
+			return slices.ContainsFunc(tags, func(tag string) bool { return normalizeTag(tag) == t })
+		})
+	})
+}
+
+// SetFavorite stars or unstars e.
+func (ix *Index) SetFavorite(e Entry, favorite bool) {
+	ix.updateLabels(e, func(l *Labels) {
+		l.Favorite = favorite
+	})
+}
+
+// TagCounts returns how many tracked images carry each tag.
+func (ix *Index) TagCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, e := range ix.Entries {
+		for _, tag := range ix.Labels[e.Hash].Tags {
+			counts[tag]++
+		}
+	}
+	return counts
+}
+
+func (ix *Index) updateLabels(e Entry, update func(*Labels)) {
+	if ix.Labels == nil {
+		ix.Labels = make(map[string]Labels)
+	}
+	l := ix.Labels[e.Hash]
+	l.Tags = slices.Clone(l.Tags)
+	update(&l)
+	if l.empty() {
+		delete(ix.Labels, e.Hash)
+		return
+	}
+	ix.Labels[e.Hash] = l
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
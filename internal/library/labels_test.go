@@ -0,0 +1,87 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLabelsSurviveMoves(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dunes.png")
+	writePNG(t, path, 8, 8)
+
+	ix, _ := Open(dir)
+	e, err := ix.Add(path, "", t0)
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	ix.AddTags(*e, "Nature", "dark", "nature", " ")
+	ix.SetFavorite(*e, true)
+	if err := ix.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	moved := filepath.Join(dir, "desert.png")
+	if err := os.Rename(path, moved); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	ix, _ = Open(dir)
+	if _, err := ix.Rescan(t0.Add(time.Hour)); err != nil {
+		t.Fatalf("rescan: %v", err)
+	}
+	e, ok := ix.Get(moved)
+	if !ok {
+		t.Fatal("expected moved file to be indexed")
+	}
+	labels := ix.LabelsOf(*e)
+	if !labels.Favorite || len(labels.Tags) != 2 || labels.Tags[0] != "dark" || labels.Tags[1] != "nature" {
+		t.Fatalf("unexpected labels after move: %+v", labels)
+	}
+
+	ix.RemoveTags(*e, "DARK", "nature")
+	ix.SetFavorite(*e, false)
+	if len(ix.Labels) != 0 {
+		t.Fatalf("expected empty labels to be dropped, got %+v", ix.Labels)
+	}
+}
+
+func TestQueryLabelsAndRandom(t *testing.T) {
+	ix := &Index{dir: t.TempDir(), Entries: []Entry{
+		{File: "a.png", Hash: "a", Added: t0},
+		{File: "b.png", Hash: "b", Added: t0, LastSet: t0},
+		{File: "c.png", Hash: "c", Added: t0},
+	}}
+	ix.AddTags(ix.Entries[0], "dark")
+	ix.AddTags(ix.Entries[1], "dark", "city")
+	ix.SetFavorite(ix.Entries[1], true)
+	ix.SetFavorite(ix.Entries[2], true)
+
+	got, _ := ix.List(Query{Tags: []string{"dark"}, Sort: "name"})
+	if len(got) != 2 || got[0].File != "a.png" || got[1].File != "b.png" {
+		t.Fatalf("unexpected tag filter result: %+v", got)
+	}
+	got, _ = ix.List(Query{Tags: []string{"Dark"}, Favorite: true})
+	if len(got) != 1 || got[0].File != "b.png" {
+		t.Fatalf("unexpected tag+fav result: %+v", got)
+	}
+
+	current, err := ix.Resolve(CurrentAlias)
+	if err != nil || current.File != "b.png" {
+		t.Fatalf("unexpected current: %+v %v", current, err)
+	}
+
+	// b.png is current, so it is only picked when nothing else matches.
+	e, err := ix.Random(Query{Tags: []string{"dark"}}, func(int) int { return 0 })
+	if err != nil || e.File != "a.png" {
+		t.Fatalf("unexpected random pick: %+v %v", e, err)
+	}
+	e, err = ix.Random(Query{Tags: []string{"city"}}, func(int) int { return 0 })
+	if err != nil || e.File != "b.png" {
+		t.Fatalf("unexpected random pick: %+v %v", e, err)
+	}
+	if _, err := ix.Random(Query{Tags: []string{"forest"}}, nil); err != ErrNoMatch {
+		t.Fatalf("expected ErrNoMatch, got %v", err)
+	}
+}
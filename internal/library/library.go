@@ -0,0 +1,392 @@
+// Package library keeps an index of the images stored in the save
+// directory: where they came from, what they are and when they were set.
+package library
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"wugo/internal/image"
+	"wugo/internal/imaging"
+)
+
+const (
+	indexFileName = "library.json"
+	lockFileName  = "library.lock"
+)
+
+// ErrNotFound is returned when a file is not tracked by the index.
+var ErrNotFound = errors.New("not in library")
+
+// Entry describes one stored image. File is relative to the save directory.
+type Entry struct {
+	File      string    `json:"file"`
+	SourceURL string    `json:"source_url,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	Title     string    `json:"title,omitempty"`
+	Hash      string    `json:"hash"`
+	DHash     string    `json:"dhash,omitempty"`
+	Width     int       `json:"width,omitempty"`
+	Height    int       `json:"height,omitempty"`
+	Format    string    `json:"format,omitempty"`
+	Size      int64     `json:"size"`
+	Added     time.Time `json:"added"`
+	TimesSet  int       `json:"times_set,omitempty"`
+	LastSet   time.Time `json:"last_set,omitzero"`
+}
+
+// Index is the library.json file in the save directory's state directory.
+type Index struct {
+	dir     string
+	path    string
+	lock    *os.File
+	Entries []Entry `json:"entries"`
+	// Labels maps content hashes to tags and favourites.
+	Labels map[string]Labels `json:"labels,omitempty"`
+}
+
+// Open loads the index of saveDir. A missing index is empty.
+func Open(saveDir string) (*Index, error) {
+	ix := &Index{
+		dir:  saveDir,
+		path: filepath.Join(image.StateDir(saveDir), indexFileName),
+	}
+
+	data, err := os.ReadFile(ix.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ix, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, ix); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ix.path, err)
+	}
+
+	return ix, nil
+}
+
+// Lock opens the index of saveDir for an update. Other processes calling
+// Lock wait until Unlock, so no change made between Lock and Save is lost
+// to a concurrent writer.
+func Lock(saveDir string) (*Index, error) {
+	state := image.StateDir(saveDir)
+	if err := os.MkdirAll(state, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(state, lockFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("lock library: %w", err)
+	}
+
+	ix, err := Open(saveDir)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	ix.lock = f
+	return ix, nil
+}
+
+// Unlock releases the lock taken by Lock. It does nothing for an index
+// from Open.
+func (ix *Index) Unlock() {
+	if ix.lock != nil {
+		_ = ix.lock.Close()
+		ix.lock = nil
+	}
+}
+
+// Save writes the index atomically.
+func (ix *Index) Save() error {
+	data, err := json.MarshalIndent(ix, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := ix.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ix.path)
+}
+
+// Dir returns the save directory the index belongs to.
+func (ix *Index) Dir() string {
+	return ix.dir
+}
+
+// Path returns the absolute path of an entry's file.
+func (ix *Index) Path(e Entry) string {
+	return filepath.Join(ix.dir, e.File)
+}
+
+// Rel converts path to an entry file name. ok is false for paths outside
+// the save directory and inside wugo's state directory, which the library
+// does not manage.
+func (ix *Index) Rel(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(ix.dir, abs)
+	if err != nil || rel == "." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || rel == ".." {
+		return "", false
+	}
+	if state, err := filepath.Rel(ix.dir, image.StateDir(ix.dir)); err == nil &&
+		(rel == state || strings.HasPrefix(rel, state+string(filepath.Separator))) {
+		return "", false
+	}
+	return rel, true
+}
+
+// Get returns the entry for path.
+func (ix *Index) Get(path string) (*Entry, bool) {
+	rel, ok := ix.Rel(path)
+	if !ok {
+		return nil, false
+	}
+	for i := range ix.Entries {
+		if ix.Entries[i].File == rel {
+			return &ix.Entries[i], true
+		}
+	}
+	return nil, false
+}
+
+// CurrentAlias names the image most recently set by wugo.
+const CurrentAlias = "current"
+
+// Resolve finds an entry by path, file name or path relative to the save
+// directory. CurrentAlias resolves to the current wallpaper.
+func (ix *Index) Resolve(name string) (*Entry, error) {
+	if name == CurrentAlias {
+		if e, ok := ix.Current(); ok {
+			return e, nil
+		}
+		return nil, fmt.Errorf("no wallpaper set yet: %w", ErrNotFound)
+	}
+	if e, ok := ix.Get(name); ok {
+		return e, nil
+	}
+	if e, ok := ix.Get(filepath.Join(ix.dir, name)); ok {
+		return e, nil
+	}
+	return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+}
+
+// Add indexes the file at path, or refreshes its entry when it is already
+// tracked. Paths outside the save directory are ignored.
+func (ix *Index) Add(path, sourceURL string, now time.Time) (*Entry, error) {
+	rel, ok := ix.Rel(path)
+	if !ok {
+		return nil, nil
+	}
+
+	fresh, err := Describe(filepath.Join(ix.dir, rel))
+	if err != nil {
+		return nil, err
+	}
+	fresh.SourceURL = sourceURL
+	return ix.Insert(path, fresh, now), nil
+}
+
+// Insert adds fresh, as returned by Describe for path, to the index. An
+// existing entry for path keeps its bookkeeping fields. Paths outside the
+// save directory are ignored and yield nil.
+func (ix *Index) Insert(path string, fresh Entry, now time.Time) *Entry {
+	rel, ok := ix.Rel(path)
+	if !ok {
+		return nil
+	}
+	fresh.File = rel
+
+	if e, ok := ix.Get(path); ok {
+		if fresh.SourceURL == "" {
+			fresh.SourceURL = e.SourceURL
+		}
+		fresh.Added = e.Added
+		fresh.TimesSet = e.TimesSet
+		fresh.LastSet = e.LastSet
+		*e = fresh
+		return e
+	}
+
+	fresh.Added = now
+	ix.Entries = append(ix.Entries, fresh)
+	return &ix.Entries[len(ix.Entries)-1]
+}
+
+// Remove drops the entry for path from the index.
+func (ix *Index) Remove(path string) bool {
+	rel, ok := ix.Rel(path)
+	if !ok {
+		return false
+	}
+	for i := range ix.Entries {
+		if ix.Entries[i].File == rel {
+			ix.Entries = append(ix.Entries[:i], ix.Entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// MarkSet records that path was set as wallpaper at now.
+func (ix *Index) MarkSet(path string, now time.Time) error {
+	e, ok := ix.Get(path)
+	if !ok {
+		var err error
+		e, err = ix.Add(path, "", now)
+		if err != nil || e == nil {
+			return err
+		}
+	}
+	e.TimesSet++
+	e.LastSet = now
+	return nil
+}
+
+// Current returns the entry set most recently.
+func (ix *Index) Current() (*Entry, bool) {
+	var current *Entry
+	for i := range ix.Entries {
+		e := &ix.Entries[i]
+		if e.LastSet.IsZero() {
+			continue
+		}
+		if current == nil || e.LastSet.After(current.LastSet) {
+			current = e
+		}
+	}
+	return current, current != nil
+}
+
+// RescanResult lists the files a rescan added to or removed from the index.
+type RescanResult struct {
+	Added   []string
+	Removed []string
+	Updated []string
+}
+
+// Rescan reconciles the index with the save directory: untracked images
+// are added, entries whose files are gone are removed and entries whose
+// size changed or that lack a perceptual hash are re-described.
+func (ix *Index) Rescan(now time.Time) (RescanResult, error) {
+	var result RescanResult
+
+	kept := ix.Entries[:0]
+	for _, e := range ix.Entries {
+		if _, err := os.Stat(ix.Path(e)); errors.Is(err, fs.ErrNotExist) {
+			result.Removed = append(result.Removed, e.File)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	ix.Entries = kept
+
+	err := filepath.WalkDir(ix.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != ix.dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !(d.Type().IsRegular() || d.Type()&fs.ModeSymlink != 0) || !image.IsImageFile(d.Name()) {
+			return nil
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		if e, ok := ix.Get(path); ok {
+			if e.Size == info.Size() && e.Hash != "" && (e.DHash != "" || e.Width == 0) {
+				return nil
+			}
+			if _, err := ix.Add(path, "", now); err != nil {
+				return err
+			}
+			result.Updated = append(result.Updated, e.File)
+			return nil
+		}
+
+		e, err := ix.Add(path, "", now)
+		if err != nil {
+			return err
+		}
+		result.Added = append(result.Added, e.File)
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	sort.Strings(result.Added)
+	return result, nil
+}
+
+// Describe reads everything the index stores about a file except its
+// name and bookkeeping fields. It is safe to call concurrently.
+func Describe(path string) (Entry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	hash, err := HashFile(path)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	e := Entry{Hash: hash, Size: info.Size()}
+	if imgInfo, err := imaging.ReadInfo(path); err == nil {
+		e.Width, e.Height, e.Format = imgInfo.Width, imgInfo.Height, imgInfo.Format
+	}
+	if e.Width > 0 {
+		if dhash, err := imaging.HashFile(path); err == nil {
+			e.DHash = imaging.FormatHash(dhash)
+		}
+	}
+	if meta, ok, err := image.ReadMetadata(path); err == nil && ok {
+		e.Source = meta.Source
+		e.Title = meta.Title
+	}
+
+	return e, nil
+}
+
+// HashFile returns the hex SHA-256 of the file contents.
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
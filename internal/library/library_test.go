@@ -0,0 +1,224 @@
+package library
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	wimage "wugo/internal/image"
+)
+
+func writePNG(t *testing.T, path string, w, h int) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+}
+
+var t0 = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func TestAddAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "forest_abc123.png")
+	writePNG(t, path, 64, 36)
+	if err := wimage.WriteMetadata(path, wimage.Metadata{Source: "wallhaven", Title: "Forest"}); err != nil {
+		t.Fatalf("metadata: %v", err)
+	}
+
+	if err := (Recorder{Now: func() time.Time { return t0 }}).Record(dir, path, "https://example.com/forest.png"); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	ix, err := Open(dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	e, ok := ix.Get(path)
+	if !ok {
+		t.Fatal("expected entry")
+	}
+	if e.File != "forest_abc123.png" || e.Width != 64 || e.Height != 36 || e.Format != "png" ||
+		e.SourceURL != "https://example.com/forest.png" || e.Source != "wallhaven" || e.Title != "Forest" ||
+		len(e.Hash) != 64 || e.Size == 0 || !e.Added.Equal(t0) {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+
+	if _, err := ix.Resolve("forest_abc123.png"); err != nil {
+		t.Fatalf("resolve by name: %v", err)
+	}
+	if _, err := ix.Resolve("missing.png"); err == nil {
+		t.Fatal("expected not found")
+	}
+}
+
+func TestRecorderIgnoresOutsidePaths(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "elsewhere.png")
+	writePNG(t, outside, 4, 4)
+
+	if err := (Recorder{}).Record(dir, outside, ""); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := MarkSet(dir, outside, t0); err != nil {
+		t.Fatalf("mark set: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".wugo")); !os.IsNotExist(err) {
+		t.Fatalf("expected no state written for outside path, got %v", err)
+	}
+}
+
+func TestMarkSetAndCurrent(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.png")
+	b := filepath.Join(dir, "b.png")
+	writePNG(t, a, 4, 4)
+	writePNG(t, b, 4, 4)
+
+	if err := MarkSet(dir, a, t0); err != nil {
+		t.Fatalf("mark a: %v", err)
+	}
+	if err := MarkSet(dir, b, t0.Add(time.Hour)); err != nil {
+		t.Fatalf("mark b: %v", err)
+	}
+	if err := MarkSet(dir, a, t0.Add(2*time.Hour)); err != nil {
+		t.Fatalf("mark a again: %v", err)
+	}
+
+	ix, err := Open(dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	current, ok := ix.Current()
+	if !ok || current.File != "a.png" || current.TimesSet != 2 {
+		t.Fatalf("unexpected current: %+v", current)
+	}
+}
+
+func TestReleaseKeepsFilesInUse(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i, name := range []string{"current.png", "starred.png", "stale.png"} {
+		path := filepath.Join(dir, name)
+		writePNG(t, path, 4+i, 4)
+		paths = append(paths, path)
+	}
+	r := Recorder{Now: func() time.Time { return t0 }}
+	for _, path := range paths[1:] {
+		if err := r.Record(dir, path, ""); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+	if err := MarkSet(dir, paths[0], t0); err != nil {
+		t.Fatalf("mark: %v", err)
+	}
+	ix, err := Lock(dir)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	e, _ := ix.Get(paths[1])
+	ix.SetFavorite(*e, true)
+	if err := ix.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	ix.Unlock()
+
+	for _, path := range paths {
+		if err := r.Release(dir, path); err != nil {
+			t.Fatalf("release %s: %v", path, err)
+		}
+	}
+	for i, path := range paths {
+		_, err := os.Stat(path)
+		if kept := err == nil; kept != (i < 2) {
+			t.Fatalf("%s: kept=%t", filepath.Base(path), kept)
+		}
+	}
+	if ix, _ := Open(dir); len(ix.Entries) != 2 {
+		t.Fatalf("expected the stale entry dropped, got %+v", ix.Entries)
+	}
+}
+
+func TestConcurrentUpdatesAreKept(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pic.png")
+	writePNG(t, path, 4, 4)
+
+	const writers = 8
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := MarkSet(dir, path, time.Unix(int64(i), 0)); err != nil {
+				t.Errorf("mark set: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	ix, err := Open(dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if e, ok := ix.Get(path); !ok || e.TimesSet != writers {
+		t.Fatalf("expected %d sets, got %+v", writers, e)
+	}
+}
+
+func TestRescan(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.png")
+	gone := filepath.Join(dir, "gone.png")
+	writePNG(t, kept, 4, 4)
+	writePNG(t, gone, 4, 4)
+
+	ix, _ := Open(dir)
+	if _, err := ix.Add(kept, "", t0); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := ix.Add(gone, "", t0); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	writePNG(t, filepath.Join(dir, "manual.png"), 8, 8)
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writePNG(t, filepath.Join(dir, "sub", "nested.png"), 8, 8)
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manual.png.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	writePNG(t, kept, 16, 16)
+
+	result, err := ix.Rescan(t0.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("rescan: %v", err)
+	}
+	if len(result.Added) != 2 || result.Added[0] != "manual.png" || result.Added[1] != filepath.Join("sub", "nested.png") {
+		t.Fatalf("unexpected added: %v", result.Added)
+	}
+	if len(result.Removed) != 1 || result.Removed[0] != "gone.png" {
+		t.Fatalf("unexpected removed: %v", result.Removed)
+	}
+	if len(result.Updated) != 1 || result.Updated[0] != "kept.png" {
+		t.Fatalf("unexpected updated: %v", result.Updated)
+	}
+	if e, _ := ix.Get(kept); e.Width != 16 || !e.Added.Equal(t0) {
+		t.Fatalf("expected refreshed entry to keep added time: %+v", e)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package library
+
+import "os"
+
+func lockFile(*os.File) error { return nil }
@@ -0,0 +1,13 @@
+//go:build unix
+
+package library
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile waits for an exclusive lock on f. Closing f releases it.
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
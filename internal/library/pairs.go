@@ -0,0 +1,75 @@
+package library
+
+import (
+	"slices"
+	"strings"
+)
+
+// Tags used to pair light and dark variants: both images carry the same
+// PairTagPrefix tag, such as "pair:forest", one of them LightTag and the
+// other DarkTag.
+const (
+	PairTagPrefix = "pair:"
+	LightTag      = "light"
+	DarkTag       = "dark"
+)
+
+// Pair is a light and a dark variant of a wallpaper, as entry file names.
+type Pair struct {
+	Light string
+	Dark  string
+}
+
+// TagPairs returns the pairs formed by tags, ordered by pair tag. Pair tags
+// without exactly one light and one dark image are ignored.
+func (ix *Index) TagPairs() []Pair {
+	type sides struct{ light, dark []string }
+	byTag := make(map[string]*sides)
+	for _, e := range ix.Entries {
+		labels := ix.LabelsOf(e)
+		for _, tag := range labels.Tags {
+			if !strings.HasPrefix(tag, PairTagPrefix) {
+				continue
+			}
+			s := byTag[tag]
+			if s == nil {
+				s = &sides{}
+				byTag[tag] = s
+			}
+			if labels.HasTag(LightTag) {
+				s.light = append(s.light, e.File)
+			}
+			if labels.HasTag(DarkTag) {
+				s.dark = append(s.dark, e.File)
+			}
+		}
+	}
+
+	tags := make([]string, 0, len(byTag))
+	for tag := range byTag {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+
+	var pairs []Pair
+	for _, tag := range tags {
+		if s := byTag[tag]; len(s.light) == 1 && len(s.dark) == 1 {
+			pairs = append(pairs, Pair{Light: s.light[0], Dark: s.dark[0]})
+		}
+	}
+	return pairs
+}
+
+// Variant returns the light or dark variant of the pair that file belongs
+// to. ok is false when file is in no pair.
+func Variant(pairs []Pair, file string, dark bool) (string, bool) {
+	for _, p := range pairs {
+		if p.Light == file || p.Dark == file {
+			if dark {
+				return p.Dark, true
+			}
+			return p.Light, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,40 @@
+package library
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestTagPairs(t *testing.T) {
+	dir := t.TempDir()
+	ix, _ := Open(dir)
+	add := func(name string, size int, tags ...string) {
+		path := filepath.Join(dir, name)
+		writePNG(t, path, size, size)
+		e, err := ix.Add(path, "", t0)
+		if err != nil {
+			t.Fatalf("add: %v", err)
+		}
+		ix.AddTags(*e, tags...)
+	}
+	add("forest-day.png", 8, "pair:forest", "light")
+	add("forest-night.png", 9, "pair:forest", "dark")
+	add("city-day.png", 10, "pair:city", "light")
+	add("loose.png", 11, "dark")
+
+	pairs := ix.TagPairs()
+	if fmt.Sprint(pairs) != "[{forest-day.png forest-night.png}]" {
+		t.Fatalf("unexpected pairs: %v", pairs)
+	}
+
+	if v, ok := Variant(pairs, "forest-day.png", true); !ok || v != "forest-night.png" {
+		t.Fatalf("expected dark variant, got %q %v", v, ok)
+	}
+	if v, ok := Variant(pairs, "forest-night.png", false); !ok || v != "forest-day.png" {
+		t.Fatalf("expected light variant, got %q %v", v, ok)
+	}
+	if _, ok := Variant(pairs, "loose.png", true); ok {
+		t.Fatal("expected unpaired image to have no variant")
+	}
+}
@@ -0,0 +1,130 @@
+package library
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is a retention policy. An image is pruned when it is not among the
+// KeepLast most recent, is older than OlderThan, or is among the oldest
+// images that must go to bring the library under MaxSize bytes. Zero
+// fields are disabled. Favourites and the current wallpaper are never
+// pruned.
+type Policy struct {
+	KeepLast  int
+	MaxSize   int64
+	OlderThan time.Duration
+}
+
+// IsZero reports whether no rule is enabled.
+func (p Policy) IsZero() bool {
+	return p.KeepLast <= 0 && p.MaxSize <= 0 && p.OlderThan <= 0
+}
+
+// Prune returns the entries p would remove, oldest first. An entry's age
+// is counted from when it was last set, or added if it never was.
+func (ix *Index) Prune(p Policy, now time.Time) []Entry {
+	entries := make([]Entry, len(ix.Entries))
+	copy(entries, ix.Entries)
+	sort.SliceStable(entries, func(i, j int) bool { return lastUsed(entries[i]).After(lastUsed(entries[j])) })
+
+	current, _ := ix.Current()
+	protected := func(e Entry) bool {
+		return ix.LabelsOf(e).Favorite || (current != nil && e.File == current.File)
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+
+	remove := make(map[string]bool)
+	for i, e := range entries {
+		if protected(e) {
+			continue
+		}
+		if p.KeepLast > 0 && i >= p.KeepLast {
+			remove[e.File] = true
+		}
+		if p.OlderThan > 0 && now.Sub(lastUsed(e)) > p.OlderThan {
+			remove[e.File] = true
+		}
+	}
+
+	if p.MaxSize > 0 {
+		for _, e := range entries {
+			if remove[e.File] {
+				total -= e.Size
+			}
+		}
+		for i := len(entries) - 1; i >= 0 && total > p.MaxSize; i-- {
+			e := entries[i]
+			if remove[e.File] || protected(e) {
+				continue
+			}
+			remove[e.File] = true
+			total -= e.Size
+		}
+	}
+
+	var out []Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if remove[entries[i].File] {
+			out = append(out, entries[i])
+		}
+	}
+	return out
+}
+
+func lastUsed(e Entry) time.Time {
+	if e.LastSet.After(e.Added) {
+		return e.LastSet
+	}
+	return e.Added
+}
+
+// ParseSize parses a byte size such as "5G", "500M", "1.5GiB" or "1024".
+// Units are powers of 1024.
+func ParseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+
+	multiplier := int64(1)
+	if s != "" {
+		if i := strings.IndexByte("KMGT", s[len(s)-1]); i >= 0 {
+			multiplier = 1 << (10 * (i + 1))
+			s = s[:len(s)-1]
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q, expected e.g. 500M or 5G", value)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// ParseAge parses a duration that may also use days and weeks, such as
+// "90d", "2w" or "36h".
+func ParseAge(value string) (time.Duration, error) {
+	s := strings.TrimSpace(value)
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit != 0 {
+		n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+		if err == nil && n >= 0 {
+			return time.Duration(n * float64(unit)), nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid age %q, expected e.g. 90d, 2w or 36h", value)
+}
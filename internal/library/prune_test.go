@@ -0,0 +1,72 @@
+package library
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	day := 24 * time.Hour
+	now := t0.Add(100 * day)
+	ix := &Index{Entries: []Entry{
+		{File: "old.png", Hash: "1", Size: 100, Added: t0},
+		{File: "old-fav.png", Hash: "2", Size: 100, Added: t0},
+		{File: "old-used.png", Hash: "3", Size: 100, Added: t0, LastSet: now.Add(-day)},
+		{File: "mid.png", Hash: "4", Size: 300, Added: t0.Add(50 * day)},
+		{File: "new.png", Hash: "5", Size: 100, Added: t0.Add(99 * day)},
+		{File: "current.png", Hash: "6", Size: 100, Added: t0, LastSet: now},
+	}}
+	ix.SetFavorite(ix.Entries[1], true)
+
+	files := func(entries []Entry) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.File)
+		}
+		return out
+	}
+	cases := []struct {
+		name   string
+		policy Policy
+		want   []string
+	}{
+		{"older than", Policy{OlderThan: 90 * day}, []string{"old.png"}},
+		{"keep last", Policy{KeepLast: 3}, []string{"old.png", "mid.png"}},
+		{"max size", Policy{MaxSize: 500}, []string{"old.png", "mid.png"}},
+		{"combined", Policy{OlderThan: 60 * day, MaxSize: 600}, []string{"old.png", "mid.png"}},
+		{"nothing", Policy{KeepLast: 10}, nil},
+	}
+	for _, tc := range cases {
+		got := files(ix.Prune(tc.policy, now))
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, got)
+			}
+		}
+	}
+}
+
+func TestParseSizeAndAge(t *testing.T) {
+	sizes := map[string]int64{"1024": 1024, "5G": 5 << 30, "500MB": 500 << 20, "1.5GiB": 3 << 29, "2k": 2048}
+	for in, want := range sizes {
+		if got, err := ParseSize(in); err != nil || got != want {
+			t.Fatalf("ParseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	if _, err := ParseSize("lots"); err == nil {
+		t.Fatal("expected error for invalid size")
+	}
+
+	ages := map[string]time.Duration{"90d": 90 * 24 * time.Hour, "2w": 14 * 24 * time.Hour, "36h": 36 * time.Hour}
+	for in, want := range ages {
+		if got, err := ParseAge(in); err != nil || got != want {
+			t.Fatalf("ParseAge(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseAge("-3d"); err == nil {
+		t.Fatal("expected error for negative age")
+	}
+}
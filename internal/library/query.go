@@ -0,0 +1,126 @@
+package library
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ErrNoMatch is returned when no library image matches a query.
+var ErrNoMatch = errors.New("no matching images in library")
+
+// Query filters and orders library entries.
+type Query struct {
+	Format    string
+	Source    string
+	MinWidth  int
+	MinHeight int
+	// Tags must all be present on a matching image.
+	Tags     []string
+	Favorite bool
+	// Sort is one of added (default), set, times, name, size or resolution.
+	Sort    string
+	Reverse bool
+	Limit   int
+}
+
+// List returns the entries matching q. Newest, most-set and largest
+// entries come first unless Reverse is set; names sort alphabetically.
+func (ix *Index) List(q Query) ([]Entry, error) {
+	less, err := sortFunc(q.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Entry
+	for _, e := range ix.Entries {
+		if q.matches(e, ix.LabelsOf(e)) {
+			out = append(out, e)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if q.Reverse {
+			return less(out[j], out[i])
+		}
+		return less(out[i], out[j])
+	})
+
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+	return out, nil
+}
+
+func (q Query) matches(e Entry, labels Labels) bool {
+	if q.Format != "" && !strings.EqualFold(normalizeFormat(q.Format), e.Format) {
+		return false
+	}
+	if q.Source != "" && !strings.EqualFold(q.Source, e.Source) &&
+		!strings.Contains(strings.ToLower(e.SourceURL), strings.ToLower(q.Source)) {
+		return false
+	}
+	if e.Width < q.MinWidth || e.Height < q.MinHeight {
+		return false
+	}
+	if q.Favorite && !labels.Favorite {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !labels.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Random picks a random entry matching q, preferring one that is not the
+// current wallpaper. randIntN defaults to math/rand/v2.IntN.
+func (ix *Index) Random(q Query, randIntN func(int) int) (*Entry, error) {
+	q.Limit = 0
+	matches, err := ix.List(q)
+	if err != nil {
+		return nil, err
+	}
+	if current, ok := ix.Current(); ok && len(matches) > 1 {
+		matches = slices.DeleteFunc(matches, func(e Entry) bool { return e.File == current.File })
+	}
+	if len(matches) == 0 {
+		return nil, ErrNoMatch
+	}
+	if randIntN == nil {
+		randIntN = rand.IntN
+	}
+	e, _ := ix.Get(ix.Path(matches[randIntN(len(matches))]))
+	return e, nil
+}
+
+func sortFunc(key string) (func(a, b Entry) bool, error) {
+	switch key {
+	case "", "added":
+		return func(a, b Entry) bool { return a.Added.After(b.Added) }, nil
+	case "set":
+		return func(a, b Entry) bool { return a.LastSet.After(b.LastSet) }, nil
+	case "times":
+		return func(a, b Entry) bool { return a.TimesSet > b.TimesSet }, nil
+	case "name":
+		return func(a, b Entry) bool { return a.File < b.File }, nil
+	case "size":
+		return func(a, b Entry) bool { return a.Size > b.Size }, nil
+	case "resolution":
+		return func(a, b Entry) bool { return a.Width*a.Height > b.Width*b.Height }, nil
+	default:
+		return nil, fmt.Errorf("unknown sort key: %s", key)
+	}
+}
+
+func normalizeFormat(format string) string {
+	format = strings.ToLower(strings.TrimPrefix(format, "."))
+	if format == "jpg" {
+		return "jpeg"
+	}
+	return format
+}
@@ -0,0 +1,46 @@
+package library
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListFiltersAndSorts(t *testing.T) {
+	ix := &Index{Entries: []Entry{
+		{File: "a.png", Format: "png", Width: 1920, Height: 1080, Size: 300, Added: t0, Source: "wallhaven"},
+		{File: "b.jpg", Format: "jpeg", Width: 3840, Height: 2160, Size: 900, Added: t0.Add(time.Hour), TimesSet: 3},
+		{File: "c.jpg", Format: "jpeg", Width: 1280, Height: 720, Size: 100, Added: t0.Add(2 * time.Hour), SourceURL: "https://i.redd.it/c.jpg"},
+	}}
+
+	cases := []struct {
+		query Query
+		want  []string
+	}{
+		{Query{}, []string{"c.jpg", "b.jpg", "a.png"}},
+		{Query{Reverse: true}, []string{"a.png", "b.jpg", "c.jpg"}},
+		{Query{Format: "jpg"}, []string{"c.jpg", "b.jpg"}},
+		{Query{MinWidth: 1920, MinHeight: 1080, Sort: "size"}, []string{"b.jpg", "a.png"}},
+		{Query{Source: "wallhaven"}, []string{"a.png"}},
+		{Query{Source: "redd.it"}, []string{"c.jpg"}},
+		{Query{Sort: "times", Limit: 1}, []string{"b.jpg"}},
+		{Query{Sort: "name"}, []string{"a.png", "b.jpg", "c.jpg"}},
+	}
+	for _, tc := range cases {
+		got, err := ix.List(tc.query)
+		if err != nil {
+			t.Fatalf("%+v: %v", tc.query, err)
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("%+v: expected %v, got %v", tc.query, tc.want, got)
+		}
+		for i := range got {
+			if got[i].File != tc.want[i] {
+				t.Fatalf("%+v: expected %v, got %v", tc.query, tc.want, got)
+			}
+		}
+	}
+
+	if _, err := ix.List(Query{Sort: "color"}); err == nil {
+		t.Fatal("expected error for unknown sort key")
+	}
+}
@@ -0,0 +1,96 @@
+package library
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Recorder adds every image the processor stores to the library of its
+// save directory. It implements image.Recorder. When Warnings is set, a
+// note is written there for images that look like one already stored.
+type Recorder struct {
+	Now      func() time.Time
+	Warnings io.Writer
+}
+
+func (r Recorder) Record(saveDir, path, sourceURL string) error {
+	if !tracked(saveDir, path) {
+		return nil
+	}
+	ix, err := Lock(saveDir)
+	if err != nil {
+		return err
+	}
+	defer ix.Unlock()
+	e, err := ix.Add(path, sourceURL, r.now())
+	if err != nil {
+		return err
+	}
+	if r.Warnings != nil {
+		if similar := ix.Similar(*e, DefaultThreshold); len(similar) > 0 {
+			fmt.Fprintf(r.Warnings, "Warning: %s looks like a near-duplicate of %s\n", e.File, similar[0].File)
+		}
+	}
+	return ix.Save()
+}
+
+// Release deletes path, a cached download that a newer one replaced,
+// unless it is the current wallpaper or carries tags or a favourite star.
+// It implements image.Releaser.
+func (r Recorder) Release(saveDir, path string) error {
+	if !tracked(saveDir, path) {
+		return nil
+	}
+	ix, err := Lock(saveDir)
+	if err != nil {
+		return err
+	}
+	defer ix.Unlock()
+	e, ok := ix.Get(path)
+	if !ok {
+		rel, _ := ix.Rel(path)
+		return ix.Delete(Entry{File: rel})
+	}
+	if current, ok := ix.Current(); ok && current.File == e.File {
+		return nil
+	}
+	if !ix.LabelsOf(*e).empty() {
+		return nil
+	}
+	if err := ix.Delete(*e); err != nil {
+		return err
+	}
+	return ix.Save()
+}
+
+// MarkSet records in the library of saveDir that path was set as wallpaper.
+// Images outside saveDir are not tracked.
+func MarkSet(saveDir, path string, now time.Time) error {
+	if !tracked(saveDir, path) {
+		return nil
+	}
+	ix, err := Lock(saveDir)
+	if err != nil {
+		return err
+	}
+	defer ix.Unlock()
+	if err := ix.MarkSet(path, now); err != nil {
+		return err
+	}
+	return ix.Save()
+}
+
+// tracked reports whether path belongs in the library of saveDir, without
+// touching the index.
+func tracked(saveDir, path string) bool {
+	_, ok := (&Index{dir: saveDir}).Rel(path)
+	return ok
+}
+
+func (r Recorder) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,141 @@
+// Package notify shows desktop notifications through the
+// org.freedesktop.Notifications D-Bus service.
+package notify
+
+import (
+	"context"
+	"errors"
+	"image"
+
+	"github.com/godbus/dbus/v5"
+	"golang.org/x/image/draw"
+)
+
+const (
+	serviceName  = "org.freedesktop.Notifications"
+	servicePath  = dbus.ObjectPath("/org/freedesktop/Notifications")
+	serviceIface = "org.freedesktop.Notifications"
+)
+
+// ThumbnailSize bounds the longer side of the image sent with a
+// notification.
+const ThumbnailSize = 128
+
+// Action is a button on a notification.
+type Action struct {
+	Key   string
+	Label string
+}
+
+// Notification is what Notify shows. Timeout is in milliseconds; zero lets
+// the server decide.
+type Notification struct {
+	AppName string
+	Summary string
+	Body    string
+	Image   image.Image
+	Actions []Action
+	Timeout int32
+}
+
+// imageData is the (iiibiiay) structure of the image-data hint.
+type imageData struct {
+	Width         int32
+	Height        int32
+	RowStride     int32
+	HasAlpha      bool
+	BitsPerSample int32
+	Channels      int32
+	Data          []byte
+}
+
+// Show displays n. With actions it then waits until the user picks one
+// and returns its key; the key is empty when the notification is closed
+// without one or ctx is done first.
+func Show(ctx context.Context, conn *dbus.Conn, n Notification) (string, error) {
+	if len(n.Actions) == 0 {
+		_, err := notify(conn, n)
+		return "", err
+	}
+
+	// Subscribe first so a quick click cannot arrive before the match rule.
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(servicePath),
+		dbus.WithMatchInterface(serviceIface),
+	}
+	if err := conn.AddMatchSignal(match...); err != nil {
+		return "", err
+	}
+	defer conn.RemoveMatchSignal(match...)
+	signals := make(chan *dbus.Signal, 8)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	id, err := notify(conn, n)
+	if err != nil {
+		return "", err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return "", nil
+		case sig, ok := <-signals:
+			if !ok {
+				return "", errors.New("D-Bus connection closed")
+			}
+			if len(sig.Body) < 2 {
+				continue
+			}
+			if got, _ := sig.Body[0].(uint32); got != id {
+				continue
+			}
+			switch sig.Name {
+			case serviceIface + ".ActionInvoked":
+				key, _ := sig.Body[1].(string)
+				return key, nil
+			case serviceIface + ".NotificationClosed":
+				return "", nil
+			}
+		}
+	}
+}
+
+func notify(conn *dbus.Conn, n Notification) (uint32, error) {
+	actions := make([]string, 0, 2*len(n.Actions))
+	for _, a := range n.Actions {
+		actions = append(actions, a.Key, a.Label)
+	}
+	hints := map[string]dbus.Variant{}
+	if n.Image != nil {
+		hints["image-data"] = dbus.MakeVariant(thumbnail(n.Image))
+	}
+
+	var id uint32
+	err := conn.Object(serviceName, servicePath).Call(serviceIface+".Notify", 0,
+		n.AppName, uint32(0), "", n.Summary, n.Body, actions, hints, n.Timeout).Store(&id)
+	return id, err
+}
+
+// thumbnail scales img to fit ThumbnailSize and converts it to RGBA rows.
+func thumbnail(img image.Image) imageData {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > ThumbnailSize || h > ThumbnailSize {
+		if w >= h {
+			w, h = ThumbnailSize, max(1, h*ThumbnailSize/w)
+		} else {
+			w, h = max(1, w*ThumbnailSize/h), ThumbnailSize
+		}
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return imageData{
+		Width:         int32(w),
+		Height:        int32(h),
+		RowStride:     int32(dst.Stride),
+		HasAlpha:      true,
+		BitsPerSample: 8,
+		Channels:      4,
+		Data:          dst.Pix,
+	}
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"context"
+	"image"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"wugo/internal/dbustest"
+)
+
+type fakeServer struct {
+	conn   *dbus.Conn
+	reply  string // action to invoke, or "" to close
+	mu     sync.Mutex
+	calls  []fakeCall
+	nextID uint32
+}
+
+type fakeCall struct {
+	summary, body string
+	actions       []string
+	hints         map[string]dbus.Variant
+}
+
+func (s *fakeServer) Notify(_ string, _ uint32, _, summary, body string, actions []string,
+	hints map[string]dbus.Variant, _ int32) (uint32, *dbus.Error) {
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	s.calls = append(s.calls, fakeCall{summary, body, actions, hints})
+	s.mu.Unlock()
+
+	if len(actions) > 0 {
+		// Another notification's signal must be ignored.
+		_ = s.conn.Emit(servicePath, serviceIface+".ActionInvoked", id+100, "other")
+		if s.reply != "" {
+			_ = s.conn.Emit(servicePath, serviceIface+".ActionInvoked", id, s.reply)
+		} else {
+			_ = s.conn.Emit(servicePath, serviceIface+".NotificationClosed", id, uint32(2))
+		}
+	}
+	return id, nil
+}
+
+func startServer(t *testing.T, address, reply string) *fakeServer {
+	t.Helper()
+	s := &fakeServer{conn: dbustest.Connect(t, address), reply: reply}
+	if err := s.conn.Export(s, servicePath, serviceIface); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if _, err := s.conn.RequestName(serviceName, dbus.NameFlagDoNotQueue); err != nil {
+		t.Fatalf("request name: %v", err)
+	}
+	return s
+}
+
+func TestShowSendsThumbnailAndReturnsAction(t *testing.T) {
+	address := dbustest.Start(t)
+	server := startServer(t, address, "favorite")
+	conn := dbustest.Connect(t, address)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	key, err := Show(ctx, conn, Notification{
+		AppName: "wugo",
+		Summary: "Wallpaper set",
+		Body:    "forest.jpg",
+		Image:   image.NewRGBA(image.Rect(0, 0, 512, 256)),
+		Actions: []Action{{"undo", "Undo"}, {"favorite", "Favorite"}},
+	})
+	if err != nil || key != "favorite" {
+		t.Fatalf("expected the favorite action, got %q (%v)", key, err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	call := server.calls[0]
+	if call.summary != "Wallpaper set" || len(call.actions) != 4 || call.actions[2] != "favorite" {
+		t.Fatalf("unexpected call: %+v", call)
+	}
+	fields, ok := call.hints["image-data"].Value().([]interface{})
+	if !ok || len(fields) != 7 {
+		t.Fatalf("unexpected image-data hint: %v", call.hints["image-data"])
+	}
+	if fields[0].(int32) != ThumbnailSize || fields[1].(int32) != ThumbnailSize/2 ||
+		len(fields[6].([]byte)) != ThumbnailSize*ThumbnailSize/2*4 {
+		t.Fatalf("unexpected thumbnail size: %v x %v", fields[0], fields[1])
+	}
+}
+
+func TestShowClosedWithoutAction(t *testing.T) {
+	address := dbustest.Start(t)
+	startServer(t, address, "")
+	conn := dbustest.Connect(t, address)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	key, err := Show(ctx, conn, Notification{Summary: "x", Actions: []Action{{"undo", "Undo"}}})
+	if err != nil || key != "" {
+		t.Fatalf("expected no action, got %q (%v)", key, err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("expected the close signal to end the wait")
+	}
+}
@@ -0,0 +1,193 @@
+package palette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Format writes a palette for one program.
+type Format struct {
+	Name string
+	// File is the name the export is written under.
+	File  string
+	Write func(w io.Writer, p Palette, wallpaper string) error
+}
+
+// Formats are the supported exports, in the order they are written.
+var Formats = []Format{
+	{"pywal", "colors.json", WritePywal},
+	{"xresources", "colors.Xresources", WriteXresources},
+	{"kitty", "kitty.conf", WriteKitty},
+	{"alacritty", "alacritty.toml", WriteAlacritty},
+	{"foot", "foot.ini", WriteFoot},
+	{"kde", "Wugo.colors", WriteKDE},
+}
+
+// LookupFormat returns the format called name.
+func LookupFormat(name string) (Format, error) {
+	i := slices.IndexFunc(Formats, func(f Format) bool { return f.Name == strings.ToLower(name) })
+	if i < 0 {
+		return Format{}, fmt.Errorf("unknown palette format %q", name)
+	}
+	return Formats[i], nil
+}
+
+var ansiNames = [8]string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// WritePywal writes pywal's colors.json, which tools built around pywal
+// read from ~/.cache/wal.
+func WritePywal(w io.Writer, p Palette, wallpaper string) error {
+	var colors bytes.Buffer
+	colors.WriteByte('{')
+	for i, c := range p.Colors {
+		if i > 0 {
+			colors.WriteByte(',')
+		}
+		fmt.Fprintf(&colors, `"color%d":%q`, i, c.Hex())
+	}
+	colors.WriteByte('}')
+
+	doc := struct {
+		Wallpaper string            `json:"wallpaper"`
+		Alpha     string            `json:"alpha"`
+		Special   map[string]string `json:"special"`
+		Colors    json.RawMessage   `json:"colors"`
+	}{
+		Wallpaper: wallpaper,
+		Alpha:     "100",
+		Special: map[string]string{
+			"background": p.Background.Hex(),
+			"foreground": p.Foreground.Hex(),
+			"cursor":     p.Cursor.Hex(),
+		},
+		Colors: colors.Bytes(),
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// WriteXresources writes X resources for xterm, urxvt and friends.
+func WriteXresources(w io.Writer, p Palette, _ string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*.background: %s\n", p.Background.Hex())
+	fmt.Fprintf(&b, "*.foreground: %s\n", p.Foreground.Hex())
+	fmt.Fprintf(&b, "*.cursorColor: %s\n", p.Cursor.Hex())
+	for i, c := range p.Colors {
+		fmt.Fprintf(&b, "*.color%d: %s\n", i, c.Hex())
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteKitty writes a kitty config snippet for include.
+func WriteKitty(w io.Writer, p Palette, _ string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "background %s\n", p.Background.Hex())
+	fmt.Fprintf(&b, "foreground %s\n", p.Foreground.Hex())
+	fmt.Fprintf(&b, "cursor %s\n", p.Cursor.Hex())
+	fmt.Fprintf(&b, "selection_background %s\n", p.Foreground.Hex())
+	fmt.Fprintf(&b, "selection_foreground %s\n", p.Background.Hex())
+	for i, c := range p.Colors {
+		fmt.Fprintf(&b, "color%d %s\n", i, c.Hex())
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteAlacritty writes an alacritty TOML snippet for general.import.
+func WriteAlacritty(w io.Writer, p Palette, _ string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[colors.primary]\nbackground = %q\nforeground = %q\n\n", p.Background.Hex(), p.Foreground.Hex())
+	fmt.Fprintf(&b, "[colors.cursor]\ncursor = %q\ntext = %q\n", p.Cursor.Hex(), p.Background.Hex())
+	for _, section := range []string{"normal", "bright"} {
+		fmt.Fprintf(&b, "\n[colors.%s]\n", section)
+		offset := 0
+		if section == "bright" {
+			offset = 8
+		}
+		for i, name := range ansiNames {
+			fmt.Fprintf(&b, "%s = %q\n", name, p.Colors[offset+i].Hex())
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteFoot writes a foot [colors] section for include.
+func WriteFoot(w io.Writer, p Palette, _ string) error {
+	hex := func(c RGB) string { return strings.TrimPrefix(c.Hex(), "#") }
+	var b strings.Builder
+	b.WriteString("[colors]\n")
+	fmt.Fprintf(&b, "background=%s\nforeground=%s\n", hex(p.Background), hex(p.Foreground))
+	for i, c := range p.Colors {
+		kind := "regular"
+		if i >= 8 {
+			kind = "bright"
+		}
+		fmt.Fprintf(&b, "%s%d=%s\n", kind, i%8, hex(c))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteKDE writes a KDE color scheme. Copied to
+// ~/.local/share/color-schemes it can be applied with
+// plasma-apply-colorscheme Wugo.
+func WriteKDE(w io.Writer, p Palette, _ string) error {
+	rgb := func(c RGB) string { return fmt.Sprintf("%d,%d,%d", c.R, c.G, c.B) }
+	bg, fg := p.Background, p.Foreground
+	accent := p.Colors[4]
+	raised := mix(bg, fg, 0.08)
+	dim := mix(fg, bg, 0.4)
+
+	var b strings.Builder
+	for _, group := range []struct {
+		name       string
+		background RGB
+	}{
+		{"Button", raised},
+		{"Complementary", bg},
+		{"Header", bg},
+		{"Tooltip", raised},
+		{"View", bg},
+		{"Window", bg},
+	} {
+		fmt.Fprintf(&b, "[Colors:%s]\n", group.name)
+		fmt.Fprintf(&b, "BackgroundAlternate=%s\n", rgb(mix(group.background, fg, 0.05)))
+		fmt.Fprintf(&b, "BackgroundNormal=%s\n", rgb(group.background))
+		fmt.Fprintf(&b, "DecorationFocus=%s\n", rgb(accent))
+		fmt.Fprintf(&b, "DecorationHover=%s\n", rgb(accent))
+		writeKDEForegrounds(&b, p, fg, dim, rgb)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("[Colors:Selection]\n")
+	fmt.Fprintf(&b, "BackgroundAlternate=%s\n", rgb(mix(accent, bg, 0.2)))
+	fmt.Fprintf(&b, "BackgroundNormal=%s\n", rgb(accent))
+	fmt.Fprintf(&b, "DecorationFocus=%s\n", rgb(accent))
+	fmt.Fprintf(&b, "DecorationHover=%s\n", rgb(accent))
+	writeKDEForegrounds(&b, p, bg, mix(bg, accent, 0.4), rgb)
+
+	b.WriteString("\n[General]\nColorScheme=Wugo\nName=Wugo\n\n")
+	b.WriteString("[WM]\n")
+	fmt.Fprintf(&b, "activeBackground=%s\nactiveForeground=%s\n", rgb(bg), rgb(fg))
+	fmt.Fprintf(&b, "inactiveBackground=%s\ninactiveForeground=%s\n", rgb(bg), rgb(dim))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeKDEForegrounds(b *strings.Builder, p Palette, normal, inactive RGB, rgb func(RGB) string) {
+	fmt.Fprintf(b, "ForegroundActive=%s\n", rgb(p.Colors[12]))
+	fmt.Fprintf(b, "ForegroundInactive=%s\n", rgb(inactive))
+	fmt.Fprintf(b, "ForegroundLink=%s\n", rgb(p.Colors[4]))
+	fmt.Fprintf(b, "ForegroundNegative=%s\n", rgb(p.Colors[1]))
+	fmt.Fprintf(b, "ForegroundNeutral=%s\n", rgb(p.Colors[3]))
+	fmt.Fprintf(b, "ForegroundNormal=%s\n", rgb(normal))
+	fmt.Fprintf(b, "ForegroundPositive=%s\n", rgb(p.Colors[2]))
+	fmt.Fprintf(b, "ForegroundVisited=%s\n", rgb(p.Colors[5]))
+}
@@ -0,0 +1,67 @@
+package palette
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testPalette() Palette {
+	p := Palette{Background: RGB{0x10, 0x10, 0x20}, Foreground: RGB{0xee, 0xee, 0xee}, Cursor: RGB{0xee, 0xee, 0xee}}
+	for i := range p.Colors {
+		p.Colors[i] = RGB{uint8(i * 16), uint8(i), 0xff}
+	}
+	return p
+}
+
+func TestWritePywal(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePywal(&buf, testPalette(), "/w/forest.jpg"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var doc struct {
+		Wallpaper string
+		Special   map[string]string
+		Colors    map[string]string
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("parse: %v\n%s", err, buf.String())
+	}
+	if doc.Wallpaper != "/w/forest.jpg" || doc.Special["background"] != "#101020" ||
+		doc.Colors["color0"] != "#0000ff" || doc.Colors["color15"] != "#f00fff" || len(doc.Colors) != 16 {
+		t.Fatalf("unexpected colors.json:\n%s", buf.String())
+	}
+	if strings.Index(buf.String(), `"color2"`) > strings.Index(buf.String(), `"color10"`) {
+		t.Fatalf("expected colors in numeric order:\n%s", buf.String())
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	expected := map[string][]string{
+		"xresources": {"*.background: #101020", "*.color15: #f00fff"},
+		"kitty":      {"foreground #eeeeee", "color1 #1001ff"},
+		"alacritty":  {"[colors.primary]", "[colors.bright]\nblack = \"#8008ff\"", "white = \"#f00fff\""},
+		"foot":       {"[colors]", "background=101020", "regular1=1001ff", "bright7=f00fff"},
+		"kde":        {"[Colors:Window]", "BackgroundNormal=16,16,32", "ForegroundNegative=16,1,255", "ColorScheme=Wugo"},
+	}
+	for name, parts := range expected {
+		f, err := LookupFormat(name)
+		if err != nil {
+			t.Fatalf("lookup %s: %v", name, err)
+		}
+		var buf bytes.Buffer
+		if err := f.Write(&buf, testPalette(), ""); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, part := range parts {
+			if !strings.Contains(buf.String(), part) {
+				t.Fatalf("%s output lacks %q:\n%s", name, part, buf.String())
+			}
+		}
+	}
+
+	if _, err := LookupFormat("vim"); err == nil {
+		t.Fatal("expected unknown format to fail")
+	}
+}
@@ -0,0 +1,292 @@
+// Package palette derives a terminal color scheme from a wallpaper.
+package palette
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// RGB is an opaque 8-bit color.
+type RGB struct {
+	R, G, B uint8
+}
+
+// Hex formats c as #rrggbb.
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Palette is a 16-color terminal scheme. Colors follow the ANSI order:
+// black, red, green, yellow, blue, magenta, cyan and white, then their
+// bright variants.
+type Palette struct {
+	Background RGB
+	Foreground RGB
+	Cursor     RGB
+	Colors     [16]RGB
+}
+
+// maxSamples caps how many pixels are read along each axis.
+const maxSamples = 256
+
+// Extract builds a palette from img: median cut finds its dominant colors,
+// the darkest becomes the background and the most colorful ones are
+// matched to the ANSI hues and lightened until they read well on it.
+func Extract(img image.Image) Palette {
+	swatches := medianCut(sample(img), 16)
+	if len(swatches) == 0 {
+		swatches = []swatch{{color: RGB{}, count: 1}}
+	}
+
+	sort.SliceStable(swatches, func(i, j int) bool {
+		return luminance(swatches[i].color) < luminance(swatches[j].color)
+	})
+	bg := swatches[0].color
+	for luminance(bg) > 0.03 {
+		bg = mix(bg, RGB{}, 0.2)
+	}
+
+	// The foreground is a near-white tinted by the lightest swatch.
+	tint := mix(swatches[len(swatches)-1].color, RGB{230, 230, 230}, 0.8)
+	fg := tint
+	for step := 0.0; contrast(fg, bg) < 10 && step < 1; step += 0.05 {
+		fg = mix(tint, RGB{255, 255, 255}, step)
+	}
+	if contrast(fg, bg) < 10 {
+		fg = RGB{255, 255, 255}
+	}
+
+	accents := pickAccents(swatches[1:])
+	for i, c := range accents {
+		for step := 0.0; contrast(c, bg) < 4.5 && step <= 1; step += 0.05 {
+			c = mix(accents[i], RGB{255, 255, 255}, step)
+		}
+		accents[i] = c
+	}
+
+	var p Palette
+	p.Background, p.Foreground, p.Cursor = bg, fg, fg
+	p.Colors[0] = bg
+	p.Colors[7] = mix(fg, bg, 0.15)
+	p.Colors[8] = mix(bg, fg, 0.35)
+	p.Colors[15] = fg
+	for i, c := range accents {
+		p.Colors[1+i] = c
+		p.Colors[9+i] = mix(c, RGB{255, 255, 255}, 0.2)
+	}
+	return p
+}
+
+type swatch struct {
+	color RGB
+	count int
+}
+
+func sample(img image.Image) []RGB {
+	b := img.Bounds()
+	stepX := max(1, b.Dx()/maxSamples)
+	stepY := max(1, b.Dy()/maxSamples)
+
+	var pixels []RGB
+	for y := b.Min.Y; y < b.Max.Y; y += stepY {
+		for x := b.Min.X; x < b.Max.X; x += stepX {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A < 128 {
+				continue
+			}
+			pixels = append(pixels, RGB{c.R, c.G, c.B})
+		}
+	}
+	return pixels
+}
+
+// medianCut splits pixels into up to n boxes, always halving the box with
+// the widest channel range at its median, and returns their averages.
+func medianCut(pixels []RGB, n int) []swatch {
+	if len(pixels) == 0 {
+		return nil
+	}
+	boxes := [][]RGB{pixels}
+	for len(boxes) < n {
+		widest, channel, span := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			if c, s := widestChannel(box); s > span {
+				widest, channel, span = i, c, s
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		box := boxes[widest]
+		sort.Slice(box, func(i, j int) bool { return channelOf(box[i], channel) < channelOf(box[j], channel) })
+		mid := splitPoint(box, channel)
+		boxes[widest] = box[:mid]
+		boxes = append(boxes, box[mid:])
+	}
+
+	swatches := make([]swatch, 0, len(boxes))
+	for _, box := range boxes {
+		var r, g, b int
+		for _, c := range box {
+			r, g, b = r+int(c.R), g+int(c.G), b+int(c.B)
+		}
+		k := len(box)
+		swatches = append(swatches, swatch{RGB{uint8(r / k), uint8(g / k), uint8(b / k)}, k})
+	}
+	return swatches
+}
+
+// splitPoint returns the index nearest the median of a box sorted by
+// channel where the channel value changes, so runs of one value stay
+// together.
+func splitPoint(box []RGB, channel int) int {
+	mid := len(box) / 2
+	for d := 0; d < len(box); d++ {
+		for _, i := range []int{mid - d, mid + d} {
+			if i > 0 && i < len(box) && channelOf(box[i-1], channel) != channelOf(box[i], channel) {
+				return i
+			}
+		}
+	}
+	return mid
+}
+
+func widestChannel(box []RGB) (channel, span int) {
+	for ch := range 3 {
+		lo, hi := 255, 0
+		for _, c := range box {
+			v := channelOf(c, ch)
+			lo, hi = min(lo, v), max(hi, v)
+		}
+		if hi-lo > span {
+			channel, span = ch, hi-lo
+		}
+	}
+	return channel, span
+}
+
+func channelOf(c RGB, ch int) int {
+	switch ch {
+	case 0:
+		return int(c.R)
+	case 1:
+		return int(c.G)
+	}
+	return int(c.B)
+}
+
+// ansiHues are the hues of red, green, yellow, blue, magenta and cyan.
+var ansiHues = [6]float64{0, 120, 60, 240, 300, 180}
+
+// pickAccents chooses the six most colorful swatches, weighted by how much
+// of the image they cover, and orders them to match ansiHues as closely as
+// possible.
+func pickAccents(swatches []swatch) [6]RGB {
+	candidates := make([]swatch, len(swatches))
+	copy(candidates, swatches)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return score(candidates[i]) > score(candidates[j])
+	})
+	if len(candidates) == 0 {
+		candidates = []swatch{{color: RGB{128, 128, 128}, count: 1}}
+	}
+	var chosen [6]RGB
+	for i := range chosen {
+		chosen[i] = candidates[i%len(candidates)].color
+	}
+
+	best, bestCost := chosen, math.Inf(1)
+	permute(chosen[:], 0, func(order []RGB) {
+		var cost float64
+		for i, c := range order {
+			h, s, _ := hsl(c)
+			d := math.Abs(h - ansiHues[i])
+			cost += min(d, 360-d) * s
+		}
+		if cost < bestCost {
+			bestCost = cost
+			copy(best[:], order)
+		}
+	})
+	return best
+}
+
+func score(s swatch) float64 {
+	_, sat, l := hsl(s.color)
+	// Favor saturated mid-tones; nearly black or white swatches make poor
+	// accents however saturated they are.
+	return sat * (1 - math.Abs(2*l-1)) * math.Sqrt(float64(s.count))
+}
+
+func permute(colors []RGB, k int, visit func([]RGB)) {
+	if k == len(colors) {
+		visit(colors)
+		return
+	}
+	for i := k; i < len(colors); i++ {
+		colors[k], colors[i] = colors[i], colors[k]
+		permute(colors, k+1, visit)
+		colors[k], colors[i] = colors[i], colors[k]
+	}
+}
+
+// hsl returns the hue in degrees and the saturation and lightness from 0
+// to 1.
+func hsl(c RGB) (h, s, l float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	hi, lo := max(r, g, b), min(r, g, b)
+	l = (hi + lo) / 2
+	if hi == lo {
+		return 0, 0, l
+	}
+	d := hi - lo
+	s = d / (1 - math.Abs(2*l-1))
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s, l
+}
+
+// luminance is the WCAG relative luminance of c.
+func luminance(c RGB) float64 {
+	linear := func(v uint8) float64 {
+		x := float64(v) / 255
+		if x <= 0.03928 {
+			return x / 12.92
+		}
+		return math.Pow((x+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
+
+// contrast is the WCAG contrast ratio between a and b, from 1 to 21.
+func contrast(a, b RGB) float64 {
+	la, lb := luminance(a), luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// mix moves a toward b by t.
+func mix(a, b RGB, t float64) RGB {
+	blend := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x)*(1-t) + float64(y)*t))
+	}
+	return RGB{blend(a.R, b.R), blend(a.G, b.G), blend(a.B, b.B)}
+}
@@ -0,0 +1,77 @@
+package palette
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	// A dark navy image with stripes of the six accent hues.
+	stripes := []color.RGBA{
+		{200, 40, 40, 255}, {40, 180, 60, 255}, {210, 190, 40, 255},
+		{50, 80, 210, 255}, {180, 50, 190, 255}, {40, 180, 190, 255},
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 120, 60))
+	for y := range 60 {
+		for x := range 120 {
+			c := color.RGBA{20, 24, 40, 255}
+			if y >= 30 {
+				c = stripes[x/20]
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	p := Extract(img)
+	if luminance(p.Background) > 0.03 {
+		t.Fatalf("expected a dark background, got %s", p.Background.Hex())
+	}
+	if contrast(p.Foreground, p.Background) < 10 {
+		t.Fatalf("foreground %s does not contrast with %s", p.Foreground.Hex(), p.Background.Hex())
+	}
+	for i := 1; i <= 6; i++ {
+		if contrast(p.Colors[i], p.Background) < 4.5 {
+			t.Fatalf("color%d %s is hard to read", i, p.Colors[i].Hex())
+		}
+	}
+	for i, expected := range ansiHues {
+		h, _, _ := hsl(p.Colors[1+i])
+		d := h - expected
+		if d < 0 {
+			d = -d
+		}
+		if min(d, 360-d) > 30 {
+			t.Fatalf("color%d %s has hue %.0f, expected about %.0f", i+1, p.Colors[1+i].Hex(), h, expected)
+		}
+	}
+}
+
+func TestExtractFlatImage(t *testing.T) {
+	img := image.NewUniform(color.RGBA{90, 90, 90, 255})
+	p := Extract(image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	if p.Background != p.Colors[0] {
+		t.Fatalf("expected color0 to be the background")
+	}
+	grey := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := range 4 {
+			grey.Set(x, y, img.C)
+		}
+	}
+	p = Extract(grey)
+	if contrast(p.Foreground, p.Background) < 10 {
+		t.Fatalf("expected a readable palette from a flat image, got %s on %s", p.Foreground.Hex(), p.Background.Hex())
+	}
+}
+
+func TestMedianCut(t *testing.T) {
+	pixels := []RGB{{0, 0, 0}, {0, 0, 10}, {250, 0, 0}, {255, 0, 0}}
+	swatches := medianCut(pixels, 2)
+	if len(swatches) != 2 {
+		t.Fatalf("expected two swatches, got %v", swatches)
+	}
+	if swatches[0].color != (RGB{0, 0, 5}) || swatches[1].color != (RGB{252, 0, 0}) {
+		t.Fatalf("unexpected swatches: %v", swatches)
+	}
+}
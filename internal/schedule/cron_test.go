@@ -0,0 +1,45 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNextAndPrev(t *testing.T) {
+	base := time.Date(2026, 10, 16, 10, 7, 30, 0, time.UTC) // Friday
+
+	cases := []struct {
+		expr       string
+		next, prev string
+	}{
+		{"*/15 * * * *", "2026-10-16 10:15", "2026-10-16 10:00"},
+		{"0 18 * * *", "2026-10-16 18:00", "2026-10-15 18:00"},
+		{"30 6 * * 1-5", "2026-10-19 06:30", "2026-10-16 06:30"},
+		{"0 9 1 * *", "2026-11-01 09:00", "2026-10-01 09:00"},
+		{"0 0 * * 7", "2026-10-18 00:00", "2026-10-11 00:00"},
+		{"@hourly", "2026-10-16 11:00", "2026-10-16 10:00"},
+		{"0 12 13 * 5", "2026-10-16 12:00", "2026-10-13 12:00"},
+		{"@yearly", "2027-01-01 00:00", "2026-01-01 00:00"},
+		{"0 0 29 2 *", "2028-02-29 00:00", "2024-02-29 00:00"},
+	}
+	for _, tc := range cases {
+		c, err := parseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.expr, err)
+		}
+		if got := c.next(base, cronSearch).Format("2006-01-02 15:04"); got != tc.next {
+			t.Fatalf("%s: next = %s, want %s", tc.expr, got, tc.next)
+		}
+		if got := c.prev(base, cronSearch).Format("2006-01-02 15:04"); got != tc.prev {
+			t.Fatalf("%s: prev = %s, want %s", tc.expr, got, tc.prev)
+		}
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Fatalf("expected error for %q", expr)
+		}
+	}
+}
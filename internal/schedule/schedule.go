@@ -0,0 +1,295 @@
+// Package schedule decides which configured wallpaper entry applies at a
+// given time.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"wugo/internal/config"
+	"wugo/internal/sun"
+)
+
+// cronSearch bounds how far a cron entry's previous and next matches are
+// searched. Eight years reach every expression that matches at all, even
+// one for February 29 across a skipped leap year.
+const cronSearch = 8 * 366 * 24 * time.Hour
+
+// Schedule is a parsed list of schedule entries.
+type Schedule struct {
+	slots []slot
+}
+
+type slot struct {
+	entry config.ScheduleEntry
+	// Daily time ranges set start and end as minutes after midnight; cron
+	// entries set expr and sun entries set sun instead.
+	start, end int
+	expr       *cron
+	sun        *sunSlot
+}
+
+// Period is a span during which one entry is active. Start is when the
+// entry last started; End is zero when the period has no known end.
+type Period struct {
+	Index int
+	Entry config.ScheduleEntry
+	Start time.Time
+	End   time.Time
+}
+
+// New parses entries. Each needs exactly one of Time, Cron and Sun and
+// exactly one of Image, Folder and Tag. Sun entries need loc.
+func New(entries []config.ScheduleEntry, loc *config.Location) (*Schedule, error) {
+	s := &Schedule{}
+	for i, e := range entries {
+		sl, err := parseSlot(e, loc)
+		if err != nil {
+			return nil, fmt.Errorf("schedule entry %d: %w", i+1, err)
+		}
+		s.slots = append(s.slots, sl)
+	}
+	return s, nil
+}
+
+func parseSlot(e config.ScheduleEntry, loc *config.Location) (slot, error) {
+	targets := 0
+	for _, v := range []string{e.Image, e.Folder, e.Tag} {
+		if v != "" {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return slot{}, fmt.Errorf("set exactly one of image, folder and tag")
+	}
+
+	triggers := 0
+	for _, v := range []string{e.Time, e.Cron, e.Sun} {
+		if v != "" {
+			triggers++
+		}
+	}
+
+	switch {
+	case triggers > 1:
+		return slot{}, fmt.Errorf("set only one of time, cron and sun")
+	case e.Sun != "":
+		if e.Sun != "day" && e.Sun != "night" {
+			return slot{}, fmt.Errorf("sun %q: expected day or night", e.Sun)
+		}
+		if loc == nil {
+			return slot{}, fmt.Errorf("sun entries need a location")
+		}
+		return slot{entry: e, sun: &sunSlot{day: e.Sun == "day", lat: loc.Latitude, lon: loc.Longitude}}, nil
+	case e.Time != "":
+		from, to, ok := strings.Cut(e.Time, "-")
+		if !ok {
+			return slot{}, fmt.Errorf("time %q: expected HH:MM-HH:MM", e.Time)
+		}
+		start, err := parseClock(from)
+		if err != nil {
+			return slot{}, err
+		}
+		end, err := parseClock(to)
+		if err != nil {
+			return slot{}, err
+		}
+		if start == end {
+			return slot{}, fmt.Errorf("time %q: empty range", e.Time)
+		}
+		return slot{entry: e, start: start, end: end}, nil
+	case e.Cron != "":
+		c, err := parseCron(e.Cron)
+		if err != nil {
+			return slot{}, err
+		}
+		return slot{entry: e, expr: &c}, nil
+	default:
+		return slot{}, fmt.Errorf("set time, cron or sun")
+	}
+}
+
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// daily returns the time of minute-of-day m on the day of t, shifted by
+// days.
+func daily(t time.Time, m, days int) time.Time {
+	y, mo, d := t.Date()
+	return time.Date(y, mo, d+days, m/60, m%60, 0, 0, t.Location())
+}
+
+func nextDaily(t time.Time, m int) time.Time {
+	if at := daily(t, m, 0); at.After(t) {
+		return at
+	}
+	return daily(t, m, 1)
+}
+
+func prevDaily(t time.Time, m int) time.Time {
+	if at := daily(t, m, 0); !at.After(t) {
+		return at
+	}
+	return daily(t, m, -1)
+}
+
+// period returns the occurrence of sl active at t.
+func (sl slot) period(t time.Time) (start, end time.Time, ok bool) {
+	if sl.expr != nil {
+		start = sl.expr.prev(t, cronSearch)
+		return start, time.Time{}, !start.IsZero()
+	}
+	if sl.sun != nil {
+		return sl.sun.period(t)
+	}
+
+	start = prevDaily(t, sl.start)
+	length := (sl.end - sl.start + 24*60) % (24 * 60)
+	end = start.Add(time.Duration(length) * time.Minute)
+	return start, end, t.Before(end)
+}
+
+// At returns the entry active at t: of all entries in effect, the one that
+// started last, with later entries winning ties. A cron entry is in effect
+// until any entry starts again.
+func (s *Schedule) At(t time.Time) (Period, bool) {
+	var best Period
+	found := false
+	for i, sl := range s.slots {
+		start, end, ok := sl.period(t)
+		if !ok || (found && start.Before(best.Start)) {
+			continue
+		}
+		if sl.expr != nil {
+			if next := s.nextStart(start); !next.IsZero() && !next.After(t) {
+				continue
+			}
+		}
+		best = Period{Index: i, Entry: sl.entry, Start: start, End: end}
+		found = true
+	}
+	if found && best.End.IsZero() {
+		best.End = s.nextStart(t)
+	}
+	return best, found
+}
+
+// Next returns the first time after t at which an entry starts or ends, or
+// the zero time if nothing is scheduled.
+func (s *Schedule) Next(t time.Time) time.Time {
+	next := s.nextStart(t)
+	for _, sl := range s.slots {
+		switch {
+		case sl.sun != nil:
+			next = earliest(next, sl.sun.next(t, !sl.sun.day))
+		case sl.expr == nil:
+			next = earliest(next, nextDaily(t, sl.end))
+		}
+	}
+	return next
+}
+
+func (s *Schedule) nextStart(t time.Time) time.Time {
+	var next time.Time
+	for _, sl := range s.slots {
+		switch {
+		case sl.expr != nil:
+			next = earliest(next, sl.expr.next(t, cronSearch))
+		case sl.sun != nil:
+			next = earliest(next, sl.sun.next(t, sl.sun.day))
+		default:
+			next = earliest(next, nextDaily(t, sl.start))
+		}
+	}
+	return next
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+// Same reports whether p and o are the same occurrence of an entry.
+func (p Period) Same(o Period) bool {
+	return p.Index == o.Index && p.Start.Equal(o.Start)
+}
+
+// Preview lists what is shown from from until from+d, with periods clipped
+// to that window. Gaps without an active entry are left out.
+func (s *Schedule) Preview(from time.Time, d time.Duration) []Period {
+	until := from.Add(d)
+	var out []Period
+	var last Period
+	for t := from; t.Before(until); {
+		next := s.Next(t)
+		if next.IsZero() || next.After(until) {
+			next = until
+		}
+		if p, ok := s.At(t); ok {
+			if n := len(out); n > 0 && p.Same(last) && out[n-1].End.Equal(t) {
+				out[n-1].End = next
+			} else {
+				last = p
+				p.Start, p.End = t, next
+				out = append(out, p)
+			}
+		}
+		t = next
+	}
+	return out
+}
+
+// Describe summarises what an entry shows, e.g. "tag dark".
+func Describe(e config.ScheduleEntry) string {
+	switch {
+	case e.Image != "":
+		return "image " + e.Image
+	case e.Folder != "":
+		return "folder " + e.Folder
+	default:
+		return "tag " + e.Tag
+	}
+}
+
+// sunWindow is how far around a moment sunrises and sunsets are searched.
+const sunWindow = 36 * time.Hour
+
+// sunSlot is active while the sun is up (day) or down at a location.
+type sunSlot struct {
+	day      bool
+	lat, lon float64
+}
+
+func (s *sunSlot) period(t time.Time) (start, end time.Time, ok bool) {
+	events := sun.Events(t.Add(-sunWindow), t.Add(sunWindow), s.lat, s.lon)
+	isDay := sun.Elevation(t, s.lat, s.lon) > sun.Horizon
+	// During polar day or night the period starts with the calendar day.
+	start = daily(t, 0, 0)
+	for _, e := range events {
+		if e.Time.After(t) {
+			end = e.Time
+			break
+		}
+		start, isDay = e.Time, e.Rise
+	}
+	return start, end, isDay == s.day
+}
+
+// next returns the next sunrise (rise) or sunset after t, or the zero time
+// during polar day or night.
+func (s *sunSlot) next(t time.Time, rise bool) time.Time {
+	for _, e := range sun.Events(t, t.Add(sunWindow), s.lat, s.lon) {
+		if e.Rise == rise && e.Time.After(t) {
+			return e.Time
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,155 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"wugo/internal/config"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2026, 10, 16, hour, minute, 0, 0, time.UTC)
+}
+
+func TestScheduleAt(t *testing.T) {
+	s, err := New([]config.ScheduleEntry{
+		{Name: "morning", Time: "06:00-12:00", Tag: "morning"},
+		{Name: "day", Time: "12:00-18:00", Folder: "/walls/day"},
+		{Name: "night", Time: "22:00-06:00", Image: "night.png"},
+		{Name: "evening", Cron: "0 18 * * *", Tag: "evening"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	cases := []struct {
+		when time.Time
+		want string
+		end  time.Time
+	}{
+		{at(7, 0), "morning", at(12, 0)},
+		{at(12, 0), "day", at(18, 0)},
+		{at(19, 30), "evening", at(22, 0)},
+		{at(23, 0), "night", at(6, 0).AddDate(0, 0, 1)},
+		{at(3, 0), "night", at(6, 0)},
+	}
+	for _, tc := range cases {
+		p, ok := s.At(tc.when)
+		if !ok || p.Entry.Name != tc.want || !p.End.Equal(tc.end) {
+			t.Fatalf("%s: got %q until %s (ok=%t), want %q until %s", tc.when, p.Entry.Name, p.End, ok, tc.want, tc.end)
+		}
+	}
+
+	if next := s.Next(at(7, 0)); !next.Equal(at(12, 0)) {
+		t.Fatalf("unexpected next boundary: %s", next)
+	}
+}
+
+func TestMonthlyEntryLastsAllMonth(t *testing.T) {
+	s, err := New([]config.ScheduleEntry{{Name: "month", Cron: "@monthly", Tag: "fresh"}}, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	p, ok := s.At(at(12, 0))
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	if !ok || !p.Start.Equal(start) || !p.End.Equal(end) {
+		t.Fatalf("expected the month's entry from %s until %s, got %+v (ok=%t)", start, end, p, ok)
+	}
+}
+
+func TestScheduleGapsAndPreview(t *testing.T) {
+	s, err := New([]config.ScheduleEntry{
+		{Name: "work", Time: "09:00-17:00", Tag: "calm"},
+		{Name: "hourly", Cron: "0 20-21 * * *", Tag: "fun"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	// Cron entries last until the next entry starts, even past midnight.
+	if p, ok := s.At(at(8, 0)); !ok || p.Entry.Name != "hourly" {
+		t.Fatalf("expected the last cron match to still apply, got %+v", p)
+	}
+
+	preview := s.Preview(at(8, 30), 24*time.Hour)
+	tomorrow := func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	want := []struct {
+		name       string
+		start, end time.Time
+	}{
+		{"hourly", at(8, 30), at(9, 0)},
+		{"work", at(9, 0), at(17, 0)},
+		{"hourly", at(20, 0), at(21, 0)},
+		{"hourly", at(21, 0), tomorrow(at(8, 30))},
+	}
+	if len(preview) != len(want) {
+		t.Fatalf("unexpected preview: %+v", preview)
+	}
+	for i, w := range want {
+		p := preview[i]
+		if p.Entry.Name != w.name || !p.Start.Equal(w.start) || !p.End.Equal(w.end) {
+			t.Fatalf("period %d: got %s %s-%s, want %s %s-%s", i, p.Entry.Name, p.Start, p.End, w.name, w.start, w.end)
+		}
+	}
+
+	// After the range ends nothing is scheduled until the cron fires.
+	if _, ok := s.At(at(18, 0)); ok {
+		t.Fatal("expected a gap after work")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	bad := []config.ScheduleEntry{
+		{Time: "06:00-12:00"},
+		{Time: "06:00-12:00", Cron: "@daily", Tag: "x"},
+		{Tag: "x"},
+		{Time: "6am-noon", Tag: "x"},
+		{Time: "06:00-06:00", Tag: "x"},
+		{Cron: "* *", Tag: "x"},
+		{Time: "06:00-12:00", Tag: "x", Image: "y"},
+		{Sun: "day", Tag: "x"},
+		{Sun: "dusk", Tag: "x"},
+		{Sun: "day", Time: "06:00-12:00", Tag: "x"},
+	}
+	for _, e := range bad {
+		if _, err := New([]config.ScheduleEntry{e}, nil); err == nil {
+			t.Fatalf("expected error for %+v", e)
+		}
+	}
+}
+
+func TestSunEntries(t *testing.T) {
+	paris := &config.Location{Latitude: 48.8566, Longitude: 2.3522}
+	s, err := New([]config.ScheduleEntry{
+		{Name: "light", Sun: "day", Tag: "light"},
+		{Name: "dark", Sun: "night", Tag: "dark"},
+	}, paris)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	noon := at(12, 0)
+	p, ok := s.At(noon)
+	if !ok || p.Entry.Name != "light" {
+		t.Fatalf("expected light at noon, got %+v", p)
+	}
+	// Mid-October in Paris: sunrise about 06:13 UTC, sunset about 17:00 UTC.
+	if p.Start.Sub(at(6, 13)).Abs() > 5*time.Minute || p.End.Sub(at(17, 0)).Abs() > 5*time.Minute {
+		t.Fatalf("unexpected day period: %s - %s", p.Start, p.End)
+	}
+	if next := s.Next(noon); !next.Equal(p.End) {
+		t.Fatalf("expected next boundary at sunset %s, got %s", p.End, next)
+	}
+
+	night, ok := s.At(at(23, 0))
+	if !ok || night.Entry.Name != "dark" || !night.Start.Equal(p.End) {
+		t.Fatalf("expected dark since sunset, got %+v", night)
+	}
+
+	preview := s.Preview(noon, 24*time.Hour)
+	if len(preview) != 3 || preview[0].Entry.Name != "light" || preview[1].Entry.Name != "dark" || preview[2].Entry.Name != "light" {
+		t.Fatalf("unexpected preview: %+v", preview)
+	}
+}
@@ -0,0 +1,113 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"wugo/internal/image"
+)
+
+const APIPrefix = "api:"
+
+// API queries a configurable JSON endpoint, such as Unsplash
+// /photos/random or a self-hosted gallery, and stores the image it points
+// to. Inputs look like "api:<name>" or "api:<name>:<query>"; the query
+// replaces {query} in URL. Authentication headers belong in the per-host
+// HTTP header rules.
+type API struct {
+	Fetcher Fetcher
+	Name    string
+	URL     string
+	// Selectors for the image URL, author, attribution link and title.
+	Image       string
+	Author      string
+	Attribution string
+	Title       string
+	Rand        func(n int) int
+}
+
+func (a *API) Match(input string) bool {
+	prefix := APIPrefix + a.Name
+	if !hasPrefix(input, prefix) {
+		return false
+	}
+	rest := input[len(prefix):]
+	return rest == "" || rest[0] == ':'
+}
+
+func (a *API) Fetch(ctx context.Context, input, dst string) (string, error) {
+	if a.URL == "" || a.Image == "" {
+		return "", fmt.Errorf("api source %s: url and image selector are required", a.Name)
+	}
+
+	query := strings.TrimPrefix(trimPrefix(input, APIPrefix+a.Name), ":")
+	endpoint := strings.ReplaceAll(a.URL, "{query}", url.QueryEscape(query))
+
+	var doc any
+	if err := getJSON(ctx, a.Fetcher, endpoint, &doc); err != nil {
+		return "", fmt.Errorf("api source %s: %w", a.Name, err)
+	}
+
+	steps, err := parseSelector(a.Image)
+	if err != nil {
+		return "", err
+	}
+	itemSteps, imageSteps := splitItems(steps)
+	type candidate struct {
+		item any
+		url  string
+	}
+	var candidates []candidate
+	for _, item := range selectSteps(doc, itemSteps) {
+		for _, v := range selectSteps(item, imageSteps) {
+			if s, ok := v.(string); ok && s != "" {
+				candidates = append(candidates, candidate{item, s})
+				break
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return "", errors.New("api source " + a.Name + ": no image URL in response")
+	}
+
+	c := candidates[pick(len(candidates), a.Rand)]
+	imageURL, err := resolveReference(endpoint, c.url)
+	if err != nil {
+		return "", err
+	}
+
+	localPath, err := a.Fetcher.Download(ctx, imageURL, dst)
+	if err != nil {
+		return "", err
+	}
+
+	meta := image.Metadata{
+		Source:      "api:" + a.Name,
+		ImageURL:    imageURL,
+		Title:       selectField(doc, c.item, itemSteps, a.Title),
+		Author:      selectField(doc, c.item, itemSteps, a.Author),
+		Attribution: selectField(doc, c.item, itemSteps, a.Attribution),
+	}
+	if err := image.WriteMetadata(localPath, meta); err != nil {
+		return "", fmt.Errorf("write metadata: %w", err)
+	}
+
+	return localPath, nil
+}
+
+// resolveReference makes relative image URLs from self-hosted galleries
+// absolute against the endpoint they came from.
+func resolveReference(base, ref string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid image URL %q: %w", ref, err)
+	}
+	return baseURL.ResolveReference(refURL).String(), nil
+}
@@ -0,0 +1,129 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"wugo/internal/image"
+)
+
+func TestAPIMatch(t *testing.T) {
+	api := &API{Name: "unsplash"}
+	if !api.Match("api:unsplash") || !api.Match("api:unsplash:mountains") {
+		t.Fatal("expected api:unsplash inputs to match")
+	}
+	if api.Match("api:unsplashed") || api.Match("unsplash:x") {
+		t.Fatal("expected other inputs not to match")
+	}
+}
+
+func TestAPIFetch(t *testing.T) {
+	var gotQuery string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/photos/random", func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("query")
+		_, _ = w.Write([]byte(`{"results": [
+			{"src": "/img/one.jpg", "user": {"name": "Ann"}, "link": "https://gallery.test/one"},
+			{"title": "no image"},
+			{"src": "/img/two.jpg", "user": {"name": "Bob"}, "link": "https://gallery.test/two", "title": "Two"}
+		], "copyright": "Gallery"}`))
+	})
+	mux.HandleFunc("/img/two.jpg", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("two"))
+	})
+
+	proc := image.NewProcessor(server.Client(), bytes.NewReader([]byte{1, 2, 3}))
+	proc.Register(&API{
+		Fetcher:     proc,
+		Name:        "gallery",
+		URL:         server.URL + "/photos/random?query={query}",
+		Image:       "results[*].src",
+		Author:      "results[*].user.name",
+		Attribution: "results[*].link",
+		Title:       "results[*].title",
+		Rand:        func(int) int { return 1 },
+	})
+
+	got, err := proc.Process(context.Background(), "api:gallery:snowy mountains", t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if gotQuery != "snowy mountains" {
+		t.Fatalf("unexpected query: %q", gotQuery)
+	}
+
+	data, err := os.ReadFile(got)
+	if err != nil || string(data) != "two" {
+		t.Fatalf("unexpected image: %q %v", data, err)
+	}
+
+	meta, ok, err := image.ReadMetadata(got)
+	if err != nil || !ok {
+		t.Fatalf("read metadata: ok=%v err=%v", ok, err)
+	}
+	if meta.Author != "Bob" || meta.Attribution != "https://gallery.test/two" || meta.Title != "Two" || meta.Source != "api:gallery" {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+}
+
+func TestAPIFetchNoImage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"urls": {}}`))
+	}))
+	defer server.Close()
+
+	api := &API{Fetcher: image.NewProcessor(server.Client(), nil), Name: "x", URL: server.URL, Image: "urls.full"}
+	if _, err := api.Fetch(context.Background(), "api:x", t.TempDir()); err == nil {
+		t.Fatal("expected error when selector finds nothing")
+	}
+}
+
+func TestAPIFetchItemWithoutAuthor(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/feed", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"photos": {
+			"b": {"src": "/img/b.jpg", "credit": "Bea"},
+			"a": {"src": "/img/a.jpg"},
+			"c": {"src": "/img/c.jpg", "credit": "Cy"}
+		}, "license": "CC BY"}`))
+	})
+	mux.HandleFunc("/img/", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("jpg"))
+	})
+
+	proc := image.NewProcessor(server.Client(), bytes.NewReader([]byte{1, 2, 3}))
+	for i, want := range []string{"", "Bea", "Cy"} {
+		api := &API{
+			Fetcher:     proc,
+			Name:        "feed",
+			URL:         server.URL + "/feed",
+			Image:       "photos[*].src",
+			Author:      "photos[*].credit",
+			Attribution: "license",
+			Rand:        func(int) int { return i },
+		}
+		got, err := api.Fetch(context.Background(), "api:feed", t.TempDir())
+		if err != nil {
+			t.Fatalf("fetch: %v", err)
+		}
+		meta, _, err := image.ReadMetadata(got)
+		if err != nil {
+			t.Fatalf("read metadata: %v", err)
+		}
+		if meta.Author != want || meta.Attribution != "CC BY" {
+			t.Fatalf("item %d: expected author %q, got %+v", i, want, meta)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"wugo/internal/image"
+)
+
+const (
+	APODPrefix      = "apod:"
+	defaultAPODBase = "https://api.nasa.gov/planetary/apod"
+	defaultAPODKey  = "DEMO_KEY"
+	apodDateLayout  = "2006-01-02"
+	// apodLookback is how many days apod:today searches back for an image
+	// when today's entry is a video.
+	apodLookback = 7
+	// apodZone is where APOD's day changes; the API rejects dates that
+	// have not begun there yet.
+	apodZone = "America/New_York"
+)
+
+// APOD stores NASA's Astronomy Picture of the Day: "apod:today" or
+// "apod:YYYY-MM-DD".
+type APOD struct {
+	Fetcher Fetcher
+	// BaseURL is the APOD API endpoint, api.nasa.gov by default.
+	BaseURL string
+	APIKey  string
+	Now     func() time.Time
+}
+
+type apodEntry struct {
+	Date      string `json:"date"`
+	Title     string `json:"title"`
+	Copyright string `json:"copyright"`
+	MediaType string `json:"media_type"`
+	URL       string `json:"url"`
+	HDURL     string `json:"hdurl"`
+}
+
+func (a *APOD) Match(input string) bool {
+	return hasPrefix(input, APODPrefix)
+}
+
+func (a *APOD) Fetch(ctx context.Context, input, dst string) (string, error) {
+	params := url.Values{"thumbs": {"false"}}
+
+	day := trimPrefix(input, APODPrefix)
+	var entries []apodEntry
+	switch day {
+	case "today", "":
+		now := a.now()
+		params.Set("start_date", now.AddDate(0, 0, -apodLookback).Format(apodDateLayout))
+		params.Set("end_date", now.Format(apodDateLayout))
+		if err := a.get(ctx, params, &entries); err != nil {
+			return "", fmt.Errorf("apod: %w", err)
+		}
+	default:
+		if _, err := time.Parse(apodDateLayout, day); err != nil {
+			return "", fmt.Errorf("invalid apod date %q, expected YYYY-MM-DD", day)
+		}
+		params.Set("date", day)
+		var entry apodEntry
+		if err := a.get(ctx, params, &entry); err != nil {
+			return "", fmt.Errorf("apod: %w", err)
+		}
+		entries = []apodEntry{entry}
+	}
+
+	entry, ok := newestAPODImage(entries)
+	if !ok {
+		return "", errors.New("apod: no image entry (videos are skipped)")
+	}
+
+	localPath, imageURL, err := downloadFirst(ctx, a.Fetcher, dst, nonEmpty(entry.HDURL, entry.URL))
+	if err != nil {
+		return "", err
+	}
+
+	meta := image.Metadata{
+		Source:    "apod",
+		PageURL:   apodPageURL(entry.Date),
+		ImageURL:  imageURL,
+		Title:     entry.Title,
+		Copyright: strings.TrimSpace(entry.Copyright),
+	}
+	if err := image.WriteMetadata(localPath, meta); err != nil {
+		return "", fmt.Errorf("write metadata: %w", err)
+	}
+
+	return localPath, nil
+}
+
+func newestAPODImage(entries []apodEntry) (apodEntry, bool) {
+	var best apodEntry
+	found := false
+	for _, e := range entries {
+		if e.MediaType != "image" || (e.URL == "" && e.HDURL == "") {
+			continue
+		}
+		if !found || e.Date > best.Date {
+			best = e
+			found = true
+		}
+	}
+	return best, found
+}
+
+// apodPageURL links the apod.nasa.gov page for a YYYY-MM-DD date.
+func apodPageURL(date string) string {
+	t, err := time.Parse(apodDateLayout, date)
+	if err != nil {
+		return ""
+	}
+	return "https://apod.nasa.gov/apod/ap" + t.Format("060102") + ".html"
+}
+
+// get queries the API with the key in a header, keeping it out of URLs
+// that error messages include.
+func (a *APOD) get(ctx context.Context, params url.Values, v any) error {
+	base := a.BaseURL
+	if base == "" {
+		base = defaultAPODBase
+	}
+	return getJSONWithKey(ctx, a.Fetcher, base+"?"+params.Encode(), "X-Api-Key", a.apiKey(), v)
+}
+
+func (a *APOD) apiKey() string {
+	if a.APIKey != "" {
+		return a.APIKey
+	}
+	return defaultAPODKey
+}
+
+// now is the current time in APOD's time zone. Without time zone data it
+// falls back to US Eastern standard time, which is never ahead of it.
+func (a *APOD) now() time.Time {
+	now := time.Now()
+	if a.Now != nil {
+		now = a.Now()
+	}
+	loc, err := time.LoadLocation(apodZone)
+	if err != nil {
+		loc = time.FixedZone("EST", -5*60*60)
+	}
+	return now.In(loc)
+}
@@ -0,0 +1,89 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"wugo/internal/image"
+)
+
+func TestAPODTodaySkipsVideos(t *testing.T) {
+	var query url.Values
+	var key string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/apod", func(w http.ResponseWriter, r *http.Request) {
+		query, key = r.URL.Query(), r.Header.Get("X-Api-Key")
+		_, _ = w.Write([]byte(`[
+			{"date": "2026-10-16", "media_type": "image", "title": "Older", "url": "` + server.URL + `/old.jpg"},
+			{"date": "2026-10-17", "media_type": "image", "title": "Nebula", "copyright": "\nJane Doe\n",
+			 "url": "` + server.URL + `/small.jpg", "hdurl": "` + server.URL + `/hd.jpg"},
+			{"date": "2026-10-18", "media_type": "video", "title": "Launch", "url": "https://youtube.test/embed"}
+		]`))
+	})
+	mux.HandleFunc("/hd.jpg", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("hd"))
+	})
+
+	proc := image.NewProcessor(server.Client(), bytes.NewReader([]byte{1, 2, 3}))
+	proc.Register(&APOD{
+		Fetcher: proc,
+		BaseURL: server.URL + "/apod",
+		// Already the 19th in UTC, but still the 18th in New York.
+		Now: func() time.Time { return time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC) },
+	})
+
+	got, err := proc.Process(context.Background(), "apod:today", t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if query.Get("end_date") != "2026-10-18" || query.Get("start_date") != "2026-10-11" || query.Has("api_key") {
+		t.Fatalf("unexpected query: %v", query)
+	}
+	if key != "DEMO_KEY" {
+		t.Fatalf("expected the key in a header, got %q", key)
+	}
+
+	data, _ := os.ReadFile(got)
+	if string(data) != "hd" {
+		t.Fatalf("expected HD image, got %q", data)
+	}
+
+	meta, ok, err := image.ReadMetadata(got)
+	if err != nil || !ok {
+		t.Fatalf("read metadata: ok=%v err=%v", ok, err)
+	}
+	if meta.Title != "Nebula" || meta.Copyright != "Jane Doe" || meta.PageURL != "https://apod.nasa.gov/apod/ap261017.html" {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+}
+
+func TestAPODDateVideo(t *testing.T) {
+	var date string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		date = r.URL.Query().Get("date")
+		_, _ = w.Write([]byte(`{"date": "2024-01-02", "media_type": "video", "url": "https://youtube.test/x"}`))
+	}))
+	defer server.Close()
+
+	a := &APOD{Fetcher: image.NewProcessor(server.Client(), nil), BaseURL: server.URL, APIKey: "key"}
+	if _, err := a.Fetch(context.Background(), "apod:2024-01-02", t.TempDir()); err == nil {
+		t.Fatal("expected error for video entry")
+	}
+	if date != "2024-01-02" {
+		t.Fatalf("unexpected date: %q", date)
+	}
+
+	if _, err := a.Fetch(context.Background(), "apod:yesterday", t.TempDir()); err == nil {
+		t.Fatal("expected error for invalid date")
+	}
+}
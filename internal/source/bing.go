@@ -0,0 +1,122 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"wugo/internal/image"
+)
+
+const (
+	BingPrefix      = "bing:"
+	defaultBingBase = "https://www.bing.com"
+)
+
+// Bing stores the Bing homepage image of the day. Inputs are "bing:daily"
+// or "bing:daily?mkt=de-DE" to pick a market.
+type Bing struct {
+	Fetcher Fetcher
+	// BaseURL is https://www.bing.com by default.
+	BaseURL string
+	Market  string
+}
+
+type bingArchive struct {
+	Images []bingImage `json:"images"`
+}
+
+type bingImage struct {
+	URL           string `json:"url"`
+	URLBase       string `json:"urlbase"`
+	Title         string `json:"title"`
+	Copyright     string `json:"copyright"`
+	CopyrightLink string `json:"copyrightlink"`
+}
+
+func (b *Bing) Match(input string) bool {
+	return hasPrefix(input, BingPrefix)
+}
+
+func (b *Bing) Fetch(ctx context.Context, input, dst string) (string, error) {
+	name, rawQuery, _ := strings.Cut(trimPrefix(input, BingPrefix), "?")
+	if name != "daily" {
+		return "", fmt.Errorf("unknown bing input %q, expected bing:daily", input)
+	}
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("parse bing options: %w", err)
+	}
+
+	market := b.Market
+	if mkt := params.Get("mkt"); mkt != "" {
+		market = mkt
+	}
+
+	base := strings.TrimRight(b.BaseURL, "/")
+	if base == "" {
+		base = defaultBingBase
+	}
+	archiveParams := url.Values{"format": {"js"}, "idx": {"0"}, "n": {"1"}}
+	if market != "" {
+		archiveParams.Set("mkt", market)
+	}
+
+	var archive bingArchive
+	if err := getJSON(ctx, b.Fetcher, base+"/HPImageArchive.aspx?"+archiveParams.Encode(), &archive); err != nil {
+		return "", fmt.Errorf("bing archive: %w", err)
+	}
+	if len(archive.Images) == 0 {
+		return "", errors.New("bing archive returned no images")
+	}
+	img := archive.Images[0]
+
+	localPath, imageURL, err := downloadFirst(ctx, b.Fetcher, dst, bingCandidates(base, img))
+	if err != nil {
+		return "", err
+	}
+
+	meta := image.Metadata{
+		Source:      "bing",
+		PageURL:     img.CopyrightLink,
+		ImageURL:    imageURL,
+		Title:       img.Title,
+		Copyright:   img.Copyright,
+		Attribution: img.CopyrightLink,
+	}
+	if err := image.WriteMetadata(localPath, meta); err != nil {
+		return "", fmt.Errorf("write metadata: %w", err)
+	}
+
+	return localPath, nil
+}
+
+// bingCandidates lists image URLs from the highest resolution down.
+func bingCandidates(base string, img bingImage) []string {
+	var urls []string
+	if img.URLBase != "" {
+		urls = append(urls, base+img.URLBase+"_UHD.jpg", base+img.URLBase+"_1920x1080.jpg")
+	}
+	if img.URL != "" {
+		urls = append(urls, base+img.URL)
+	}
+	return urls
+}
+
+// downloadFirst tries urls in order and returns the first one stored.
+func downloadFirst(ctx context.Context, f Fetcher, dst string, urls []string) (string, string, error) {
+	err := errors.New("no image URL")
+	for _, u := range urls {
+		var localPath string
+		localPath, err = f.Download(ctx, u, dst)
+		if err == nil {
+			return localPath, u, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return "", "", err
+}
@@ -0,0 +1,101 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"wugo/internal/image"
+)
+
+func TestBingFetchPrefersUHD(t *testing.T) {
+	var market string
+	var requested []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/HPImageArchive.aspx", func(w http.ResponseWriter, r *http.Request) {
+		market = r.URL.Query().Get("mkt")
+		_, _ = w.Write([]byte(`{"images": [{
+			"url": "/th?id=OHR.Fjord_DE123_1920x1080.jpg",
+			"urlbase": "/th?id=OHR.Fjord_DE123",
+			"title": "Fjord",
+			"copyright": "Fjord, Norway (© Someone)",
+			"copyrightlink": "https://www.bing.com/search?q=fjord"
+		}]}`))
+	})
+	mux.HandleFunc("/th", func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		requested = append(requested, id)
+		if id != "OHR.Fjord_DE123_UHD.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("uhd"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	proc := image.NewProcessor(server.Client(), bytes.NewReader([]byte{1, 2, 3}))
+	proc.Register(&Bing{Fetcher: proc, BaseURL: server.URL, Market: "en-US"})
+
+	got, err := proc.Process(context.Background(), "bing:daily?mkt=de-DE", t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if market != "de-DE" {
+		t.Fatalf("expected input market to win, got %q", market)
+	}
+	if len(requested) != 1 {
+		t.Fatalf("expected only the UHD request, got %v", requested)
+	}
+
+	data, _ := os.ReadFile(got)
+	if string(data) != "uhd" {
+		t.Fatalf("unexpected image: %q", data)
+	}
+
+	meta, ok, err := image.ReadMetadata(got)
+	if err != nil || !ok {
+		t.Fatalf("read metadata: ok=%v err=%v", ok, err)
+	}
+	if meta.Title != "Fjord" || meta.Copyright != "Fjord, Norway (© Someone)" {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+}
+
+func TestBingFetchFallsBack(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/HPImageArchive.aspx", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"images": [{"url": "/th?id=OHR.X_1920x1080.jpg", "urlbase": "/th?id=OHR.X"}]}`))
+	})
+	mux.HandleFunc("/th", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") != "OHR.X_1920x1080.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("hd"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	b := &Bing{Fetcher: image.NewProcessor(server.Client(), nil), BaseURL: server.URL}
+	got, err := b.Fetch(context.Background(), "bing:daily", t.TempDir())
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	data, _ := os.ReadFile(got)
+	if string(data) != "hd" {
+		t.Fatalf("unexpected image: %q", data)
+	}
+}
+
+func TestBingUnknownInput(t *testing.T) {
+	b := &Bing{Fetcher: image.NewProcessor(nil, nil)}
+	if _, err := b.Fetch(context.Background(), "bing:weekly", t.TempDir()); err == nil {
+		t.Fatal("expected error for unknown bing input")
+	}
+}
@@ -0,0 +1,345 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"wugo/internal/image"
+)
+
+const (
+	FeedPrefix    = "feed:"
+	feedStateFile = "feeds.json"
+	// maxSeenPerFeed bounds the remembered GUIDs of a single feed.
+	maxSeenPerFeed = 1000
+)
+
+// Feed stores an image from an RSS 2.0, Atom or Media RSS feed:
+// "feed:https://example.com/rss.xml" picks the newest image not stored
+// before, "feed:https://example.com/rss.xml#random" any image. Stored
+// GUIDs are remembered in the save directory so polling never repeats.
+type Feed struct {
+	Fetcher Fetcher
+	Rand    func(n int) int
+}
+
+type feedDoc struct {
+	Channel struct {
+		Title string     `xml:"title"`
+		Items []feedItem `xml:"item"`
+	} `xml:"channel"`
+	Title   string     `xml:"title"`
+	Entries []feedItem `xml:"entry"`
+}
+
+type feedItem struct {
+	Title      string      `xml:"title"`
+	GUID       string      `xml:"guid"`
+	ID         string      `xml:"id"`
+	Author     string      `xml:"author>name"`
+	Creator    string      `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Links      []feedLink  `xml:"link"`
+	PubDate    string      `xml:"pubDate"`
+	Published  string      `xml:"published"`
+	Updated    string      `xml:"updated"`
+	Enclosures []feedMedia `xml:"enclosure"`
+	Media      []feedMedia `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroup []struct {
+		Media []feedMedia `xml:"http://search.yahoo.com/mrss/ content"`
+	} `xml:"http://search.yahoo.com/mrss/ group"`
+	Credit string `xml:"http://search.yahoo.com/mrss/ credit"`
+}
+
+type feedLink struct {
+	Href  string `xml:"href,attr"`
+	Rel   string `xml:"rel,attr"`
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type feedMedia struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Medium string `xml:"medium,attr"`
+	Width  int    `xml:"width,attr"`
+}
+
+type feedImage struct {
+	guid     string
+	imageURL string
+	pageURL  string
+	title    string
+	author   string
+	date     time.Time
+}
+
+func (f *Feed) Match(input string) bool {
+	return hasPrefix(input, FeedPrefix)
+}
+
+func (f *Feed) Fetch(ctx context.Context, input, dst string) (string, error) {
+	feedURL, random := strings.CutSuffix(trimPrefix(input, FeedPrefix), "#random")
+	if _, err := url.ParseRequestURI(feedURL); err != nil {
+		return "", fmt.Errorf("invalid feed URL %q", feedURL)
+	}
+
+	images, err := f.load(ctx, feedURL)
+	if err != nil {
+		return "", err
+	}
+	if len(images) == 0 {
+		return "", errors.New("feed has no image items")
+	}
+
+	state, err := loadFeedState(dst)
+	if err != nil {
+		return "", fmt.Errorf("load feed state: %w", err)
+	}
+
+	var chosen feedImage
+	if random {
+		chosen = images[pick(len(images), f.Rand)]
+	} else {
+		unseen := state.unseen(feedURL, images)
+		if len(unseen) == 0 {
+			return "", errors.New("feed has no new images")
+		}
+		chosen = unseen[0]
+	}
+
+	localPath, err := f.Fetcher.Download(ctx, chosen.imageURL, dst)
+	if err != nil {
+		return "", err
+	}
+
+	meta := image.Metadata{
+		Source:      "feed",
+		PageURL:     chosen.pageURL,
+		ImageURL:    chosen.imageURL,
+		Title:       chosen.title,
+		Author:      chosen.author,
+		Attribution: chosen.pageURL,
+	}
+	if err := image.WriteMetadata(localPath, meta); err != nil {
+		return "", fmt.Errorf("write metadata: %w", err)
+	}
+
+	if err := state.markSeen(feedURL, chosen.guid); err != nil {
+		return "", fmt.Errorf("save feed state: %w", err)
+	}
+
+	return localPath, nil
+}
+
+// load fetches and parses the feed, returning image items newest first.
+func (f *Feed) load(ctx context.Context, feedURL string) ([]feedImage, error) {
+	resp, err := f.Fetcher.Get(ctx, feedURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed: bad status: %s", resp.Status)
+	}
+
+	var doc feedDoc
+	decoder := xml.NewDecoder(io.LimitReader(resp.Body, maxJSONSize))
+	decoder.Strict = false
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse feed: %w", err)
+	}
+
+	items := append(doc.Channel.Items, doc.Entries...)
+	images := make([]feedImage, 0, len(items))
+	for _, item := range items {
+		img, ok := item.image(feedURL)
+		if ok {
+			images = append(images, img)
+		}
+	}
+
+	sort.SliceStable(images, func(i, j int) bool {
+		return images[i].date.After(images[j].date)
+	})
+
+	return images, nil
+}
+
+func (item feedItem) image(feedURL string) (feedImage, bool) {
+	imageURL := item.imageURL()
+	if imageURL == "" {
+		return feedImage{}, false
+	}
+	if resolved, err := resolveReference(feedURL, imageURL); err == nil {
+		imageURL = resolved
+	}
+
+	pageURL := item.pageURL()
+	guid := firstNonBlank(strings.TrimSpace(item.GUID), strings.TrimSpace(item.ID), pageURL, imageURL)
+
+	return feedImage{
+		guid:     guid,
+		imageURL: imageURL,
+		pageURL:  pageURL,
+		title:    strings.TrimSpace(item.Title),
+		author:   strings.TrimSpace(firstNonBlank(item.Credit, item.Creator, item.Author)),
+		date:     parseFeedDate(firstNonBlank(item.PubDate, item.Published, item.Updated)),
+	}, true
+}
+
+// imageURL prefers the widest Media RSS image, then enclosures.
+func (item feedItem) imageURL() string {
+	media := append([]feedMedia(nil), item.Media...)
+	for _, group := range item.MediaGroup {
+		media = append(media, group.Media...)
+	}
+
+	best := ""
+	bestWidth := -1
+	for _, m := range media {
+		if m.URL == "" || !isImageMedia(m.Medium, m.Type) {
+			continue
+		}
+		if m.Width > bestWidth {
+			best = m.URL
+			bestWidth = m.Width
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	for _, e := range item.Enclosures {
+		if e.URL != "" && strings.HasPrefix(e.Type, "image/") {
+			return e.URL
+		}
+	}
+	for _, l := range item.Links {
+		if l.Rel == "enclosure" && l.Href != "" && strings.HasPrefix(l.Type, "image/") {
+			return l.Href
+		}
+	}
+
+	return ""
+}
+
+func (item feedItem) pageURL() string {
+	for _, l := range item.Links {
+		if l.Href != "" && (l.Rel == "" || l.Rel == "alternate") {
+			return l.Href
+		}
+		if value := strings.TrimSpace(l.Value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func isImageMedia(medium, mediaType string) bool {
+	if medium != "" {
+		return medium == "image"
+	}
+	return mediaType == "" || strings.HasPrefix(mediaType, "image/")
+}
+
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+}
+
+func parseFeedDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// firstNonBlank returns the first value that is not empty or whitespace.
+func firstNonBlank(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// feedState remembers which item GUIDs of each feed were already stored.
+type feedState struct {
+	path  string
+	Feeds map[string][]string `json:"feeds"`
+}
+
+func loadFeedState(saveDir string) (*feedState, error) {
+	s := &feedState{
+		path:  filepath.Join(image.StateDir(saveDir), feedStateFile),
+		Feeds: map[string][]string{},
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Feeds == nil {
+		s.Feeds = map[string][]string{}
+	}
+	return s, nil
+}
+
+func (s *feedState) unseen(feedURL string, images []feedImage) []feedImage {
+	seen := make(map[string]bool, len(s.Feeds[feedURL]))
+	for _, guid := range s.Feeds[feedURL] {
+		seen[guid] = true
+	}
+
+	var out []feedImage
+	for _, img := range images {
+		if !seen[img.guid] {
+			out = append(out, img)
+		}
+	}
+	return out
+}
+
+func (s *feedState) markSeen(feedURL, guid string) error {
+	seen := append(s.Feeds[feedURL], guid)
+	if len(seen) > maxSeenPerFeed {
+		seen = seen[len(seen)-maxSeenPerFeed:]
+	}
+	s.Feeds[feedURL] = seen
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o644)
+}
@@ -0,0 +1,141 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"wugo/internal/image"
+)
+
+const rssFeed = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel>
+	<title>Photo blog</title>
+	<item>
+		<title>Old enclosure</title>
+		<guid>old</guid>
+		<link>https://blog.test/old</link>
+		<pubDate>Mon, 02 Jan 2006 15:04:05 +0000</pubDate>
+		<enclosure url="/img/old.jpg" type="image/jpeg" length="3"/>
+	</item>
+	<item>
+		<title>Text only</title>
+		<guid>text</guid>
+		<pubDate>Wed, 04 Jan 2006 15:04:05 +0000</pubDate>
+	</item>
+	<item>
+		<title>Newest media</title>
+		<guid>new</guid>
+		<link>https://blog.test/new</link>
+		<dc:creator>Ann</dc:creator>
+		<pubDate>Tue, 03 Jan 2006 15:04:05 +0000</pubDate>
+		<media:group>
+			<media:content url="/img/new-small.jpg" medium="image" width="640"/>
+			<media:content url="/img/new-large.jpg" medium="image" width="3840"/>
+			<media:content url="/img/new.mp4" medium="video" width="4096"/>
+		</media:group>
+	</item>
+</channel>
+</rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Atom photos</title>
+	<entry>
+		<id>urn:photo:1</id>
+		<title>Atom image</title>
+		<updated>2024-05-01T10:00:00Z</updated>
+		<author><name>Bob</name></author>
+		<link rel="alternate" href="https://atom.test/1"/>
+		<link rel="enclosure" type="image/png" href="/img/atom.png"/>
+		<content type="html">&lt;p&gt;hello&lt;/p&gt;</content>
+	</entry>
+</feed>`
+
+func newFeedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rss.xml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(rssFeed))
+	})
+	mux.HandleFunc("/atom.xml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(atomFeed))
+	})
+	mux.HandleFunc("/img/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte(strings.TrimPrefix(r.URL.Path, "/img/")))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFeedPollsNewestUnseen(t *testing.T) {
+	server := newFeedServer(t)
+	saveDir := t.TempDir()
+	proc := image.NewProcessor(server.Client(), bytes.NewReader(bytes.Repeat([]byte{1, 2, 3, 4, 5, 6}, 4)))
+	proc.Register(&Feed{Fetcher: proc})
+	input := "feed:" + server.URL + "/rss.xml"
+
+	first, err := proc.Process(context.Background(), input, saveDir, false)
+	if err != nil {
+		t.Fatalf("first poll: %v", err)
+	}
+	if data, _ := os.ReadFile(first); string(data) != "new-large.jpg" {
+		t.Fatalf("expected widest media of newest item, got %q", data)
+	}
+	meta, ok, err := image.ReadMetadata(first)
+	if err != nil || !ok || meta.Author != "Ann" || meta.PageURL != "https://blog.test/new" {
+		t.Fatalf("unexpected metadata: %+v ok=%v err=%v", meta, ok, err)
+	}
+
+	second, err := proc.Process(context.Background(), input, saveDir, false)
+	if err != nil {
+		t.Fatalf("second poll: %v", err)
+	}
+	if data, _ := os.ReadFile(second); string(data) != "old.jpg" {
+		t.Fatalf("expected older enclosure on second poll, got %q", data)
+	}
+
+	if _, err := proc.Process(context.Background(), input, saveDir, false); err == nil {
+		t.Fatal("expected no new images on third poll")
+	}
+}
+
+func TestFeedRandom(t *testing.T) {
+	server := newFeedServer(t)
+	f := &Feed{Fetcher: image.NewProcessor(server.Client(), nil), Rand: func(n int) int { return n - 1 }}
+	input := "feed:" + server.URL + "/rss.xml#random"
+
+	for i := 0; i < 2; i++ {
+		got, err := f.Fetch(context.Background(), input, t.TempDir())
+		if err != nil {
+			t.Fatalf("fetch: %v", err)
+		}
+		if data, _ := os.ReadFile(got); string(data) != "old.jpg" {
+			t.Fatalf("unexpected random pick: %q", data)
+		}
+	}
+}
+
+func TestFeedAtom(t *testing.T) {
+	server := newFeedServer(t)
+	f := &Feed{Fetcher: image.NewProcessor(server.Client(), nil)}
+
+	got, err := f.Fetch(context.Background(), "feed:"+server.URL+"/atom.xml", t.TempDir())
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if data, _ := os.ReadFile(got); string(data) != "atom.png" {
+		t.Fatalf("unexpected image: %q", data)
+	}
+	meta, _, _ := image.ReadMetadata(got)
+	if meta.Title != "Atom image" || meta.Author != "Bob" || meta.PageURL != "https://atom.test/1" {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+}
@@ -0,0 +1,163 @@
+package source
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// selectJSON evaluates a small JSONPath subset against a decoded JSON value:
+// dotted keys with optional [n] indexes and [*] wildcards, optionally rooted
+// at "$", e.g. "urls.full", "$.results[*].image.url" or "[0].src".
+// It returns every matching value in document order; object members
+// matched by a wildcard come in key order.
+func selectJSON(doc any, selector string) ([]any, error) {
+	steps, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	return selectSteps(doc, steps), nil
+}
+
+func selectSteps(value any, steps []selectorStep) []any {
+	current := []any{value}
+	for _, step := range steps {
+		var next []any
+		for _, value := range current {
+			next = append(next, step.apply(value)...)
+		}
+		current = next
+	}
+	return current
+}
+
+// splitItems splits a selector after its last wildcard: the first part
+// selects the result items, the rest a value within each item.
+func splitItems(steps []selectorStep) (items, rest []selectorStep) {
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].wildcard {
+			return steps[:i+1], steps[i+1:]
+		}
+	}
+	return nil, steps
+}
+
+type selectorStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func (s selectorStep) apply(value any) []any {
+	switch {
+	case s.wildcard:
+		switch v := value.(type) {
+		case []any:
+			return v
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			out := make([]any, 0, len(v))
+			for _, key := range keys {
+				out = append(out, v[key])
+			}
+			return out
+		}
+	case s.isIndex:
+		if arr, ok := value.([]any); ok {
+			idx := s.index
+			if idx < 0 {
+				idx += len(arr)
+			}
+			if idx >= 0 && idx < len(arr) {
+				return []any{arr[idx]}
+			}
+		}
+	default:
+		if obj, ok := value.(map[string]any); ok {
+			if item, ok := obj[s.key]; ok {
+				return []any{item}
+			}
+		}
+	}
+	return nil
+}
+
+func parseSelector(selector string) ([]selectorStep, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(selector), "$")
+	var steps []selectorStep
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("selector %q: unclosed [", selector)
+			}
+			inner := strings.Trim(rest[1:end], `"'`)
+			rest = rest[end+1:]
+			if inner == "*" {
+				steps = append(steps, selectorStep{wildcard: true})
+				continue
+			}
+			if idx, err := strconv.Atoi(inner); err == nil {
+				steps = append(steps, selectorStep{index: idx, isIndex: true})
+				continue
+			}
+			if inner == "" {
+				return nil, fmt.Errorf("selector %q: empty brackets", selector)
+			}
+			steps = append(steps, selectorStep{key: inner})
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			rest = rest[end:]
+			if key == "*" {
+				steps = append(steps, selectorStep{wildcard: true})
+			} else {
+				steps = append(steps, selectorStep{key: key})
+			}
+		}
+	}
+
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("empty selector %q", selector)
+	}
+	return steps, nil
+}
+
+// selectField returns the string selector finds for one result item, which
+// itemSteps selected from doc. Selectors that start with itemSteps are
+// evaluated within the item; others must match a single value in doc.
+// Empty selectors and missing values yield "".
+func selectField(doc, item any, itemSteps []selectorStep, selector string) string {
+	if selector == "" {
+		return ""
+	}
+	steps, err := parseSelector(selector)
+	if err != nil {
+		return ""
+	}
+
+	var values []any
+	if len(steps) >= len(itemSteps) && slices.Equal(steps[:len(itemSteps)], itemSteps) {
+		values = selectSteps(item, steps[len(itemSteps):])
+	} else {
+		values = selectSteps(doc, steps)
+	}
+	if len(values) != 1 {
+		return ""
+	}
+	s, _ := values[0].(string)
+	return s
+}
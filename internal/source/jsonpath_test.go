@@ -0,0 +1,50 @@
+package source
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSelectJSON(t *testing.T) {
+	var doc any
+	data := `{"urls": {"full": "a.jpg"}, "results": [{"src": "1.jpg"}, {"src": "2.jpg"}, {"other": true}], "list": ["x", "y"]}`
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	cases := []struct {
+		selector string
+		want     []string
+	}{
+		{"urls.full", []string{"a.jpg"}},
+		{"$.urls.full", []string{"a.jpg"}},
+		{"$['urls'].full", []string{"a.jpg"}},
+		{"results[*].src", []string{"1.jpg", "2.jpg"}},
+		{"results[1].src", []string{"2.jpg"}},
+		{"results[-2].src", []string{"2.jpg"}},
+		{"list[0]", []string{"x"}},
+		{"missing.key", nil},
+	}
+	for _, tc := range cases {
+		values, err := selectJSON(doc, tc.selector)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.selector, err)
+		}
+		if len(values) != len(tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.selector, tc.want, values)
+		}
+		for i := range values {
+			if values[i] != tc.want[i] {
+				t.Fatalf("%s: expected %v, got %v", tc.selector, tc.want, values)
+			}
+		}
+	}
+}
+
+func TestSelectJSONInvalid(t *testing.T) {
+	for _, selector := range []string{"", "$", "a[0", "a[]"} {
+		if _, err := selectJSON(map[string]any{}, selector); err == nil {
+			t.Fatalf("expected error for %q", selector)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"wugo/internal/image"
+	"wugo/internal/imaging"
+)
+
+const (
+	RedditPrefix      = "reddit:"
+	defaultRedditBase = "https://www.reddit.com"
+	redditLimit       = "100"
+)
+
+// Reddit picks an image post from a subreddit listing, e.g.
+// "reddit:r/EarthPorn?sort=top&t=day&min=2560x1440". NSFW posts are
+// skipped unless AllowNSFW is set or the input has nsfw=1.
+type Reddit struct {
+	Fetcher Fetcher
+	// BaseURL is https://www.reddit.com by default.
+	BaseURL string
+	// MinResolution is the default WIDTHxHEIGHT lower bound.
+	MinResolution string
+	AllowNSFW     bool
+	Rand          func(n int) int
+}
+
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Data redditPost `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+type redditPost struct {
+	Title         string                         `json:"title"`
+	Author        string                         `json:"author"`
+	Permalink     string                         `json:"permalink"`
+	URL           string                         `json:"url"`
+	Over18        bool                           `json:"over_18"`
+	IsGallery     bool                           `json:"is_gallery"`
+	GalleryData   *redditGallery                 `json:"gallery_data"`
+	MediaMetadata map[string]redditMediaMetadata `json:"media_metadata"`
+	Preview       *redditPreview                 `json:"preview"`
+}
+
+type redditGallery struct {
+	Items []struct {
+		MediaID string `json:"media_id"`
+	} `json:"items"`
+}
+
+type redditMediaMetadata struct {
+	Status string `json:"status"`
+	E      string `json:"e"`
+	S      struct {
+		U string `json:"u"`
+		X int    `json:"x"`
+		Y int    `json:"y"`
+	} `json:"s"`
+}
+
+type redditPreview struct {
+	Images []struct {
+		Source struct {
+			URL    string `json:"url"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+		} `json:"source"`
+	} `json:"images"`
+}
+
+type redditCandidate struct {
+	url    string
+	width  int
+	height int
+	post   redditPost
+}
+
+func (r *Reddit) Match(input string) bool {
+	return hasPrefix(input, RedditPrefix)
+}
+
+func (r *Reddit) Fetch(ctx context.Context, input, dst string) (string, error) {
+	listingURL, minW, minH, allowNSFW, err := r.listingURL(trimPrefix(input, RedditPrefix))
+	if err != nil {
+		return "", err
+	}
+
+	var listing redditListing
+	if err := getJSON(ctx, r.Fetcher, listingURL, &listing); err != nil {
+		return "", fmt.Errorf("reddit listing: %w", err)
+	}
+
+	var candidates []redditCandidate
+	for _, child := range listing.Data.Children {
+		post := child.Data
+		if post.Over18 && !allowNSFW {
+			continue
+		}
+		for _, c := range redditImages(post) {
+			if c.width < minW || c.height < minH {
+				continue
+			}
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", errors.New("reddit: no image posts match the filters")
+	}
+
+	chosen := candidates[pick(len(candidates), r.Rand)]
+	localPath, err := r.Fetcher.Download(ctx, chosen.url, dst)
+	if err != nil {
+		return "", err
+	}
+
+	meta := image.Metadata{
+		Source:   "reddit",
+		ImageURL: chosen.url,
+		Title:    chosen.post.Title,
+	}
+	if chosen.post.Author != "" {
+		meta.Author = "u/" + chosen.post.Author
+	}
+	if chosen.post.Permalink != "" {
+		meta.PageURL = "https://www.reddit.com" + chosen.post.Permalink
+		meta.Attribution = meta.PageURL
+	}
+	if err := image.WriteMetadata(localPath, meta); err != nil {
+		return "", fmt.Errorf("write metadata: %w", err)
+	}
+
+	return localPath, nil
+}
+
+// listingURL turns "r/Name?sort=top&t=day" into the JSON listing URL and
+// the effective filters.
+func (r *Reddit) listingURL(spec string) (string, int, int, bool, error) {
+	sub, rawQuery, _ := strings.Cut(spec, "?")
+	sub = strings.Trim(strings.TrimPrefix(strings.Trim(sub, "/"), "r/"), "/")
+	if sub == "" || strings.ContainsAny(sub, "/ ") {
+		return "", 0, 0, false, fmt.Errorf("invalid subreddit %q, expected reddit:r/<name>", spec)
+	}
+
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", 0, 0, false, fmt.Errorf("parse reddit options: %w", err)
+	}
+
+	sort := params.Get("sort")
+	switch sort {
+	case "":
+		sort = "hot"
+	case "hot", "new", "top", "rising", "controversial":
+	default:
+		return "", 0, 0, false, fmt.Errorf("unknown reddit sort: %s", sort)
+	}
+
+	minRes := r.MinResolution
+	if v := params.Get("min"); v != "" {
+		minRes = v
+	}
+	minW, minH, err := imaging.ParseResolution(minRes)
+	if err != nil {
+		return "", 0, 0, false, err
+	}
+
+	allowNSFW := r.AllowNSFW
+	if v := params.Get("nsfw"); v != "" {
+		allowNSFW, _ = strconv.ParseBool(v)
+	}
+
+	query := url.Values{"limit": {redditLimit}, "raw_json": {"1"}}
+	if t := params.Get("t"); t != "" {
+		query.Set("t", t)
+	}
+
+	base := strings.TrimRight(r.BaseURL, "/")
+	if base == "" {
+		base = defaultRedditBase
+	}
+
+	return fmt.Sprintf("%s/r/%s/%s.json?%s", base, url.PathEscape(sub), sort, query.Encode()), minW, minH, allowNSFW, nil
+}
+
+// redditImages returns the downloadable images of a post: every gallery
+// item, or the linked image for i.redd.it and imgur posts.
+func redditImages(post redditPost) []redditCandidate {
+	if post.IsGallery && post.GalleryData != nil {
+		var out []redditCandidate
+		for _, item := range post.GalleryData.Items {
+			media, ok := post.MediaMetadata[item.MediaID]
+			if !ok || media.S.U == "" || (media.E != "" && media.E != "Image") {
+				continue
+			}
+			out = append(out, redditCandidate{url: media.S.U, width: media.S.X, height: media.S.Y, post: post})
+		}
+		return out
+	}
+
+	imageURL, ok := directImageURL(post.URL)
+	if !ok {
+		return nil
+	}
+
+	c := redditCandidate{url: imageURL, post: post}
+	if post.Preview != nil && len(post.Preview.Images) > 0 {
+		c.width = post.Preview.Images[0].Source.Width
+		c.height = post.Preview.Images[0].Source.Height
+	}
+	return []redditCandidate{c}
+}
+
+// directImageURL recognises direct image links and rewrites bare imgur
+// pages (imgur.com/abc) to their i.imgur.com image.
+func directImageURL(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	ext := strings.ToLower(path.Ext(u.Path))
+	switch ext {
+	case ".jpg", ".jpeg", ".png", ".webp":
+		return raw, true
+	}
+
+	if (host == "imgur.com" || host == "www.imgur.com") && ext == "" {
+		id := strings.Trim(u.Path, "/")
+		if id == "" || strings.Contains(id, "/") {
+			return "", false
+		}
+		return "https://i.imgur.com/" + id + ".jpg", true
+	}
+
+	return "", false
+}
@@ -0,0 +1,142 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"wugo/internal/image"
+)
+
+const redditListingJSON = `{"data": {"children": [
+	{"data": {"title": "Spicy", "over_18": true, "url": "https://i.redd.it/nsfw.jpg",
+		"preview": {"images": [{"source": {"width": 4000, "height": 3000}}]}}},
+	{"data": {"title": "Tiny", "url": "https://i.redd.it/tiny.jpg",
+		"preview": {"images": [{"source": {"width": 800, "height": 600}}]}}},
+	{"data": {"title": "Text post", "url": "https://www.reddit.com/r/EarthPorn/comments/x"}},
+	{"data": {"title": "Gallery", "author": "hiker", "permalink": "/r/EarthPorn/comments/g1/gallery/",
+		"is_gallery": true,
+		"gallery_data": {"items": [{"media_id": "m1"}, {"media_id": "m2"}]},
+		"media_metadata": {
+			"m1": {"status": "valid", "e": "Image", "s": {"u": "GALLERY_SMALL", "x": 1024, "y": 768}},
+			"m2": {"status": "valid", "e": "Image", "s": {"u": "GALLERY_BIG", "x": 3840, "y": 2160}}
+		}}}
+]}}`
+
+func TestRedditFetch(t *testing.T) {
+	var listingPath, sortWindow, userAgent string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/r/EarthPorn/top.json", func(w http.ResponseWriter, r *http.Request) {
+		listingPath = r.URL.Path
+		sortWindow = r.URL.Query().Get("t")
+		userAgent = r.Header.Get("User-Agent")
+		body := bytes.ReplaceAll([]byte(redditListingJSON), []byte("GALLERY_BIG"), []byte(server.URL+"/big.jpg"))
+		body = bytes.ReplaceAll(body, []byte("GALLERY_SMALL"), []byte(server.URL+"/small.jpg"))
+		_, _ = w.Write(body)
+	})
+	mux.HandleFunc("/big.jpg", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("big"))
+	})
+
+	rules := image.HeaderRules{Default: http.Header{"User-Agent": []string{"wugo-test/1.0"}}}
+	proc := image.NewProcessor(server.Client(), bytes.NewReader([]byte{1, 2, 3}), image.WithHeaders(rules))
+	var candidates int
+	proc.Register(&Reddit{
+		Fetcher: proc,
+		BaseURL: server.URL,
+		Rand: func(n int) int {
+			candidates = n
+			return 0
+		},
+	})
+
+	got, err := proc.Process(context.Background(), "reddit:r/EarthPorn?sort=top&t=day&min=1920x1080", t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if listingPath != "/r/EarthPorn/top.json" || sortWindow != "day" {
+		t.Fatalf("unexpected listing request: %s t=%s", listingPath, sortWindow)
+	}
+	if userAgent != "wugo-test/1.0" {
+		t.Fatalf("expected shared User-Agent rule, got %q", userAgent)
+	}
+	if candidates != 1 {
+		t.Fatalf("expected only the large gallery item to qualify, got %d", candidates)
+	}
+
+	data, _ := os.ReadFile(got)
+	if string(data) != "big" {
+		t.Fatalf("unexpected image: %q", data)
+	}
+
+	meta, ok, err := image.ReadMetadata(got)
+	if err != nil || !ok {
+		t.Fatalf("read metadata: ok=%v err=%v", ok, err)
+	}
+	if meta.Author != "u/hiker" || meta.PageURL != "https://www.reddit.com/r/EarthPorn/comments/g1/gallery/" {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+}
+
+func TestRedditNSFWOptIn(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(redditListingJSON))
+	}))
+	defer server.Close()
+
+	var urls []string
+	fetcher := &recordingFetcher{Fetcher: image.NewProcessor(server.Client(), nil), downloads: &urls}
+	r := &Reddit{Fetcher: fetcher, BaseURL: server.URL, Rand: func(int) int { return 0 }}
+
+	if _, err := r.Fetch(context.Background(), "reddit:r/EarthPorn?nsfw=1&min=3000x2000", t.TempDir()); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(urls) != 1 || urls[0] != "https://i.redd.it/nsfw.jpg" {
+		t.Fatalf("expected NSFW post with opt-in, got %v", urls)
+	}
+}
+
+func TestDirectImageURL(t *testing.T) {
+	cases := map[string]string{
+		"https://i.redd.it/abc.jpg":     "https://i.redd.it/abc.jpg",
+		"https://i.imgur.com/xyz.png":   "https://i.imgur.com/xyz.png",
+		"https://imgur.com/AbC12":       "https://i.imgur.com/AbC12.jpg",
+		"https://imgur.com/a/album":     "",
+		"https://v.redd.it/video":       "",
+		"https://example.com/page.html": "",
+	}
+	for in, want := range cases {
+		got, ok := directImageURL(in)
+		if ok != (want != "") || got != want {
+			t.Fatalf("%s: expected %q, got %q (ok=%v)", in, want, got, ok)
+		}
+	}
+}
+
+func TestRedditInvalidInput(t *testing.T) {
+	r := &Reddit{}
+	for _, spec := range []string{"", "r/a/b", "r/pics?sort=best", "r/pics?min=big"} {
+		if _, _, _, _, err := r.listingURL(spec); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+}
+
+// recordingFetcher records download URLs without fetching them.
+type recordingFetcher struct {
+	Fetcher
+	downloads *[]string
+}
+
+func (f *recordingFetcher) Download(_ context.Context, imageURL, dst string) (string, error) {
+	*f.downloads = append(*f.downloads, imageURL)
+	return filepath.Join(dst, "recorded.jpg"), nil
+}
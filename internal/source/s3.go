@@ -0,0 +1,303 @@
+package source
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"wugo/internal/image"
+)
+
+const (
+	S3Prefix         = "s3://"
+	defaultS3Region  = "us-east-1"
+	defaultProfile   = "default"
+	s3MaxListPages   = 10
+	awsCredsFileRel  = ".aws/credentials"
+	awsConfigFileRel = ".aws/config"
+)
+
+// Requester sends prepared requests through the shared HTTP client.
+// image.Processor implements it.
+type Requester interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// S3 copies objects from S3-compatible storage such as MinIO:
+// "s3://bucket/key.jpg" fetches one object, "s3://bucket/prefix/" a random
+// image under the prefix. Credentials come from the standard AWS
+// environment variables or the shared credentials and config files.
+type S3 struct {
+	Client Requester
+	Storer Storer
+	// Endpoint is a custom S3 endpoint such as http://localhost:9000.
+	// Custom endpoints use path-style addressing.
+	Endpoint string
+	Region   string
+	Profile  string
+	Getenv   func(string) string
+	HomeDir  func() (string, error)
+	Now      func() time.Time
+	Rand     func(n int) int
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+type s3Target struct {
+	endpoint  *url.URL
+	pathStyle bool
+	bucket    string
+	region    string
+	creds     *awsCredentials
+}
+
+func (s *S3) Match(input string) bool {
+	return hasPrefix(input, S3Prefix)
+}
+
+func (s *S3) Fetch(ctx context.Context, input, dst string) (string, error) {
+	bucket, key, _ := strings.Cut(input[len(S3Prefix):], "/")
+	if bucket == "" {
+		return "", errors.New("s3 URL has no bucket")
+	}
+
+	target, err := s.target(bucket)
+	if err != nil {
+		return "", err
+	}
+
+	if key == "" || strings.HasSuffix(key, "/") {
+		key, err = s.pickObject(ctx, target, key)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	resp, err := s.send(ctx, target, "/"+key, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	localPath, err := s.Storer.Store(resp.Body, path.Base(key), dst)
+	if err != nil {
+		return "", err
+	}
+
+	meta := image.Metadata{Source: "s3", ImageURL: S3Prefix + bucket + "/" + key}
+	if err := image.WriteMetadata(localPath, meta); err != nil {
+		return "", fmt.Errorf("write metadata: %w", err)
+	}
+
+	return localPath, nil
+}
+
+// pickObject lists image keys under prefix with ListObjectsV2 and returns a
+// random one.
+func (s *S3) pickObject(ctx context.Context, target s3Target, prefix string) (string, error) {
+	var keys []string
+	token := ""
+	for page := 0; page < s3MaxListPages; page++ {
+		query := url.Values{"list-type": {"2"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.send(ctx, target, "/", query)
+		if err != nil {
+			return "", err
+		}
+		var result s3ListResult
+		err = xml.NewDecoder(io.LimitReader(resp.Body, maxJSONSize)).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("parse s3 listing: %w", err)
+		}
+
+		for _, obj := range result.Contents {
+			if obj.Size > 0 && image.IsImageFile(obj.Key) {
+				keys = append(keys, obj.Key)
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	if len(keys) == 0 {
+		return "", fmt.Errorf("no images under s3://%s/%s", target.bucket, prefix)
+	}
+	sort.Strings(keys)
+	return keys[pick(len(keys), s.Rand)], nil
+}
+
+func (s *S3) send(ctx context.Context, target s3Target, objectPath string, query url.Values) (*http.Response, error) {
+	u := *target.endpoint
+	escaped := awsEscape(objectPath, false)
+	if target.pathStyle {
+		escaped = strings.TrimRight(u.EscapedPath(), "/") + "/" + awsEscape(target.bucket, true) + escaped
+	} else {
+		u.Host = target.bucket + "." + u.Host
+	}
+	raw := u.Scheme + "://" + u.Host + escaped
+	if len(query) > 0 {
+		raw += "?" + canonicalQuery(query)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, raw, nil)
+	if err != nil {
+		return nil, err
+	}
+	if target.creds != nil {
+		signV4(req, *target.creds, target.region, "s3", s.now())
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 %s: bad status: %s %s", objectPath, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (s *S3) target(bucket string) (s3Target, error) {
+	profile := firstNonBlank(s.Profile, s.getenv("AWS_PROFILE"), defaultProfile)
+
+	region := firstNonBlank(s.Region, s.getenv("AWS_REGION"), s.getenv("AWS_DEFAULT_REGION"))
+	if region == "" {
+		region = s.profileValue(firstNonBlank(s.getenv("AWS_CONFIG_FILE"), s.homePath(awsConfigFileRel)), "profile "+profile, profile, "region")
+	}
+	if region == "" {
+		region = defaultS3Region
+	}
+
+	endpoint := firstNonBlank(s.Endpoint, s.getenv("AWS_ENDPOINT_URL_S3"), s.getenv("AWS_ENDPOINT_URL"))
+	pathStyle := endpoint != ""
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return s3Target{}, fmt.Errorf("invalid s3 endpoint %q", endpoint)
+	}
+
+	return s3Target{
+		endpoint:  u,
+		pathStyle: pathStyle,
+		bucket:    bucket,
+		region:    region,
+		creds:     s.credentials(profile),
+	}, nil
+}
+
+// credentials resolves keys from the environment, then the shared
+// credentials file. nil means anonymous access.
+func (s *S3) credentials(profile string) *awsCredentials {
+	if id, secret := s.getenv("AWS_ACCESS_KEY_ID"), s.getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return &awsCredentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: s.getenv("AWS_SESSION_TOKEN")}
+	}
+
+	file := firstNonBlank(s.getenv("AWS_SHARED_CREDENTIALS_FILE"), s.homePath(awsCredsFileRel))
+	id := s.profileValue(file, profile, profile, "aws_access_key_id")
+	secret := s.profileValue(file, profile, profile, "aws_secret_access_key")
+	if id == "" || secret == "" {
+		return nil
+	}
+	return &awsCredentials{
+		AccessKeyID:     id,
+		SecretAccessKey: secret,
+		SessionToken:    s.profileValue(file, profile, profile, "aws_session_token"),
+	}
+}
+
+// profileValue reads key from the first matching [section] of an AWS INI
+// file. The config file names sections "profile x", except for default.
+func (s *S3) profileValue(file, section, fallbackSection, key string) string {
+	if file == "" {
+		return ""
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	values := map[string]map[string]string{}
+	current := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		if values[current] == nil {
+			values[current] = map[string]string{}
+		}
+		values[current][strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+
+	if v := values[section][key]; v != "" {
+		return v
+	}
+	return values[fallbackSection][key]
+}
+
+func (s *S3) homePath(rel string) string {
+	homeDir := s.HomeDir
+	if homeDir == nil {
+		homeDir = os.UserHomeDir
+	}
+	home, err := homeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, filepath.FromSlash(rel))
+}
+
+func (s *S3) getenv(key string) string {
+	if s.Getenv != nil {
+		return s.Getenv(key)
+	}
+	return os.Getenv(key)
+}
+
+func (s *S3) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,217 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"wugo/internal/image"
+)
+
+var s3TestTime = time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+// fakeS3 implements path-style ListObjectsV2 and GetObject for one bucket
+// and checks every request's SigV4 signature.
+type fakeS3 struct {
+	t       *testing.T
+	bucket  string
+	objects map[string]string
+	creds   awsCredentials
+	region  string
+	pages   int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.validSignature(r) {
+		http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if key == "" && r.URL.Query().Get("list-type") == "2" {
+		f.pages++
+		prefix := r.URL.Query().Get("prefix")
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		// Serve one key per page to exercise continuation.
+		token := r.URL.Query().Get("continuation-token")
+		var b strings.Builder
+		b.WriteString("<ListBucketResult>")
+		start := 0
+		fmt.Sscanf(token, "%d", &start)
+		sort.Strings(keys)
+		if start < len(keys) {
+			fmt.Fprintf(&b, "<Contents><Key>%s</Key><Size>%d</Size></Contents>", keys[start], len(f.objects[keys[start]]))
+		}
+		if start+1 < len(keys) {
+			fmt.Fprintf(&b, "<IsTruncated>true</IsTruncated><NextContinuationToken>%d</NextContinuationToken>", start+1)
+		}
+		b.WriteString("</ListBucketResult>")
+		_, _ = w.Write([]byte(b.String()))
+		return
+	}
+
+	body, ok := f.objects[key]
+	if !ok {
+		http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+		return
+	}
+	_, _ = w.Write([]byte(body))
+}
+
+func (f *fakeS3) validSignature(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	_, signed, ok := strings.Cut(auth, "SignedHeaders=")
+	if !ok {
+		return false
+	}
+	signed, _, _ = strings.Cut(signed, ",")
+
+	check, err := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	if err != nil {
+		return false
+	}
+	for _, name := range strings.Split(signed, ";") {
+		if name != "host" && name != "x-amz-date" && name != "x-amz-content-sha256" {
+			check.Header.Set(name, r.Header.Get(name))
+		}
+	}
+	signV4(check, f.creds, f.region, "s3", s3TestTime)
+	return check.Header.Get("Authorization") == auth
+}
+
+func newS3Fixture(t *testing.T) (*fakeS3, *httptest.Server) {
+	t.Helper()
+	fake := &fakeS3{
+		t:      t,
+		bucket: "walls",
+		objects: map[string]string{
+			"nature/forest.jpg":    "forest",
+			"nature/my lake.png":   "lake",
+			"nature/readme.txt":    "skip",
+			"city/skyline.webp":    "city",
+			"nature/empty.jpg":     "",
+			"nature/deep/cave.jpg": "cave",
+		},
+		creds:  awsCredentials{AccessKeyID: "minio", SecretAccessKey: "minio-secret"},
+		region: "eu-central-1",
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func envMap(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func TestS3FetchKey(t *testing.T) {
+	fake, server := newS3Fixture(t)
+	proc := image.NewProcessor(server.Client(), bytes.NewReader([]byte{1, 2, 3}))
+	proc.Register(&S3{
+		Client:   proc,
+		Storer:   proc,
+		Endpoint: server.URL,
+		Getenv: envMap(map[string]string{
+			"AWS_ACCESS_KEY_ID":     fake.creds.AccessKeyID,
+			"AWS_SECRET_ACCESS_KEY": fake.creds.SecretAccessKey,
+			"AWS_REGION":            fake.region,
+		}),
+		Now: func() time.Time { return s3TestTime },
+	})
+
+	got, err := proc.Process(context.Background(), "s3://walls/nature/my lake.png", t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if data, _ := os.ReadFile(got); string(data) != "lake" {
+		t.Fatalf("unexpected contents: %q", data)
+	}
+	if base := filepath.Base(got); base != "my lake_010203.png" {
+		t.Fatalf("unexpected file name: %s", base)
+	}
+}
+
+func TestS3FetchPrefixWithProfileFiles(t *testing.T) {
+	fake, server := newS3Fixture(t)
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, ".aws"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	creds := "[default]\naws_access_key_id = wrong\naws_secret_access_key = wrong\n\n" +
+		"[nas]\naws_access_key_id = minio\naws_secret_access_key = minio-secret\n"
+	config := "[profile nas]\nregion = eu-central-1\n"
+	if err := os.WriteFile(filepath.Join(home, ".aws", "credentials"), []byte(creds), 0o600); err != nil {
+		t.Fatalf("write credentials: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(config), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	var candidates int
+	s := &S3{
+		Client:  image.NewProcessor(server.Client(), nil),
+		Storer:  image.NewProcessor(nil, nil),
+		Getenv:  envMap(map[string]string{"AWS_PROFILE": "nas", "AWS_ENDPOINT_URL_S3": server.URL}),
+		HomeDir: func() (string, error) { return home, nil },
+		Now:     func() time.Time { return s3TestTime },
+		Rand: func(n int) int {
+			candidates = n
+			return 1
+		},
+	}
+
+	got, err := s.Fetch(context.Background(), "s3://walls/nature/", t.TempDir())
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if candidates != 3 {
+		t.Fatalf("expected 3 non-empty images under prefix, got %d", candidates)
+	}
+	if fake.pages != 5 {
+		t.Fatalf("expected paginated listing, got %d pages", fake.pages)
+	}
+	if data, _ := os.ReadFile(got); string(data) != "forest" {
+		t.Fatalf("unexpected contents: %q", data)
+	}
+	meta, _, _ := image.ReadMetadata(got)
+	if meta.ImageURL != "s3://walls/nature/forest.jpg" {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+}
+
+func TestS3BadSignature(t *testing.T) {
+	_, server := newS3Fixture(t)
+	s := &S3{
+		Client:   image.NewProcessor(server.Client(), nil),
+		Storer:   image.NewProcessor(nil, nil),
+		Endpoint: server.URL,
+		Getenv: envMap(map[string]string{
+			"AWS_ACCESS_KEY_ID":     "minio",
+			"AWS_SECRET_ACCESS_KEY": "nope",
+		}),
+		HomeDir: func() (string, error) { return t.TempDir(), nil },
+		Now:     func() time.Time { return s3TestTime },
+	}
+
+	if _, err := s.Fetch(context.Background(), "s3://walls/nature/forest.jpg", t.TempDir()); err == nil {
+		t.Fatal("expected error for rejected signature")
+	}
+}
@@ -0,0 +1,266 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"wugo/internal/httpclient"
+	"wugo/internal/image"
+)
+
+const (
+	SFTPPrefix      = "sftp://"
+	defaultSSHPort  = "22"
+	sshAuthSockEnv  = "SSH_AUTH_SOCK"
+	knownHostsRel   = ".ssh/known_hosts"
+	homeRelativeDir = "~/"
+)
+
+// Storer copies a fetched file into the save directory. image.Processor
+// implements it.
+type Storer interface {
+	Store(src io.Reader, name, dst string) (string, error)
+}
+
+// SFTP copies images from an SSH server, e.g. a NAS:
+// "sftp://user@nas/photos/img.jpg" or a directory to pick a random image
+// from. Hosts are verified against known_hosts and keys come from
+// ssh-agent; paths starting with /~/ are relative to the remote home.
+type SFTP struct {
+	Storer Storer
+	// KnownHostsFile defaults to ~/.ssh/known_hosts.
+	KnownHostsFile string
+	// AgentSocket defaults to $SSH_AUTH_SOCK.
+	AgentSocket string
+	// Auth is tried after the agent keys.
+	Auth []ssh.AuthMethod
+	// Timeout bounds connecting and the SSH handshake. Defaults to
+	// httpclient.DefaultTimeout, like downloads.
+	Timeout time.Duration
+	// Dial connects to the server, e.g. through the download proxy.
+	// Defaults to a direct connection.
+	Dial httpclient.DialFunc
+	// Offline refuses every fetch with httpclient.ErrOffline.
+	Offline bool
+	HomeDir func() (string, error)
+	Rand    func(n int) int
+}
+
+func (s *SFTP) Match(input string) bool {
+	return hasPrefix(input, SFTPPrefix)
+}
+
+func (s *SFTP) Fetch(ctx context.Context, input, dst string) (string, error) {
+	if s.Offline {
+		return "", fmt.Errorf("%w: %s", httpclient.ErrOffline, input)
+	}
+	u, err := url.Parse(input)
+	if err != nil {
+		return "", fmt.Errorf("parse sftp URL: %w", err)
+	}
+	if u.Hostname() == "" {
+		return "", errors.New("sftp URL has no host")
+	}
+
+	client, closeClient, err := s.connect(ctx, u)
+	if err != nil {
+		return "", err
+	}
+	defer closeClient()
+
+	remotePath := u.Path
+	if rest, ok := strings.CutPrefix(remotePath, "/"+homeRelativeDir); ok {
+		remotePath = rest
+	}
+	if remotePath == "" {
+		remotePath = "."
+	}
+
+	info, err := client.Stat(remotePath)
+	if err != nil {
+		return "", fmt.Errorf("sftp stat %s: %w", remotePath, err)
+	}
+	if info.IsDir() {
+		remotePath, err = s.pickFile(client, remotePath)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	file, err := client.Open(remotePath)
+	if err != nil {
+		return "", fmt.Errorf("sftp open %s: %w", remotePath, err)
+	}
+	defer file.Close()
+
+	localPath, err := s.Storer.Store(file, path.Base(remotePath), dst)
+	if err != nil {
+		return "", err
+	}
+
+	remote := url.URL{Scheme: "sftp", Host: u.Host, Path: path.Join("/", remotePath)}
+	if name := u.User.Username(); name != "" {
+		remote.User = url.User(name)
+	}
+	if err := image.WriteMetadata(localPath, image.Metadata{Source: "sftp", ImageURL: remote.String()}); err != nil {
+		return "", fmt.Errorf("write metadata: %w", err)
+	}
+
+	return localPath, nil
+}
+
+func (s *SFTP) pickFile(client *sftp.Client, dir string) (string, error) {
+	entries, err := client.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("sftp list %s: %w", dir, err)
+	}
+
+	var images []string
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() && image.IsImageFile(entry.Name()) {
+			images = append(images, path.Join(dir, entry.Name()))
+		}
+	}
+	if len(images) == 0 {
+		return "", fmt.Errorf("no images in remote directory %s", dir)
+	}
+	sort.Strings(images)
+
+	return images[pick(len(images), s.Rand)], nil
+}
+
+func (s *SFTP) connect(ctx context.Context, u *url.URL) (*sftp.Client, func(), error) {
+	hostKeys, err := s.hostKeyCallback()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	auth, closeAgent := s.authMethods()
+	cleanup := []func(){closeAgent}
+	closeAll := func() {
+		for i := len(cleanup) - 1; i >= 0; i-- {
+			cleanup[i]()
+		}
+	}
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = httpclient.DefaultTimeout
+	}
+	config := &ssh.ClientConfig{
+		User:            sshUser(u),
+		Auth:            auth,
+		HostKeyCallback: hostKeys,
+		Timeout:         timeout,
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = defaultSSHPort
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+
+	dial := s.Dial
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	conn, err := dial(dialCtx, "tcp", addr)
+	cancel()
+	if err != nil {
+		closeAll()
+		return nil, nil, err
+	}
+	// An unresponsive host must not hang the handshake; afterwards only the
+	// context deadline, if any, applies.
+	ctxDeadline, _ := ctx.Deadline()
+	deadline := time.Now().Add(timeout)
+	if !ctxDeadline.IsZero() && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	_ = conn.SetDeadline(deadline)
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		_ = conn.Close()
+		closeAll()
+		return nil, nil, fmt.Errorf("ssh %s: %w", addr, err)
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+	cleanup = append(cleanup, func() { _ = sshClient.Close() })
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		closeAll()
+		return nil, nil, fmt.Errorf("sftp session: %w", err)
+	}
+	cleanup = append(cleanup, func() { _ = client.Close() })
+	_ = conn.SetDeadline(ctxDeadline)
+
+	return client, closeAll, nil
+}
+
+func (s *SFTP) authMethods() ([]ssh.AuthMethod, func()) {
+	socket := s.AgentSocket
+	if socket == "" {
+		socket = os.Getenv(sshAuthSockEnv)
+	}
+
+	var methods []ssh.AuthMethod
+	closeAgent := func() {}
+	if socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+			closeAgent = func() { _ = conn.Close() }
+		}
+	}
+
+	return append(methods, s.Auth...), closeAgent
+}
+
+func (s *SFTP) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	file := s.KnownHostsFile
+	if file == "" {
+		homeDir := s.HomeDir
+		if homeDir == nil {
+			homeDir = os.UserHomeDir
+		}
+		home, err := homeDir()
+		if err != nil {
+			return nil, err
+		}
+		file = filepath.Join(home, filepath.FromSlash(knownHostsRel))
+	}
+
+	callback, err := knownhosts.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("load known_hosts: %w", err)
+	}
+	return callback, nil
+}
+
+func sshUser(u *url.URL) string {
+	if name := u.User.Username(); name != "" {
+		return name
+	}
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,253 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"wugo/internal/image"
+)
+
+type sshFixture struct {
+	addr       string
+	knownHosts string
+	agentSock  string
+	root       string
+}
+
+// startSSHServer runs an in-process SSH server with an SFTP subsystem that
+// accepts only the key held by a test ssh-agent.
+func startSSHServer(t *testing.T) sshFixture {
+	t.Helper()
+	dir := t.TempDir()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("host key: %v", err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatalf("host signer: %v", err)
+	}
+
+	_, userPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("user key: %v", err)
+	}
+	userSigner, err := ssh.NewSignerFromKey(userPriv)
+	if err != nil {
+		t.Fatalf("user signer: %v", err)
+	}
+
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: userPriv}); err != nil {
+		t.Fatalf("agent add: %v", err)
+	}
+	agentSock := filepath.Join(dir, "agent.sock")
+	agentLn, err := net.Listen("unix", agentSock)
+	if err != nil {
+		t.Fatalf("agent listen: %v", err)
+	}
+	t.Cleanup(func() { _ = agentLn.Close() })
+	go func() {
+		for {
+			conn, err := agentLn.Accept()
+			if err != nil {
+				return
+			}
+			go func() { _ = agent.ServeAgent(keyring, conn) }()
+		}
+	}()
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if meta.User() == "nas" && bytes.Equal(key.Marshal(), userSigner.PublicKey().Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, config)
+		}
+	}()
+
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(ln.Addr().String())}, hostSigner.PublicKey())
+	if err := os.WriteFile(knownHostsPath, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatalf("write known_hosts: %v", err)
+	}
+
+	root := filepath.Join(dir, "library")
+	if err := os.MkdirAll(filepath.Join(root, "landscapes"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	return sshFixture{addr: ln.Addr().String(), knownHosts: knownHostsPath, agentSock: agentSock, root: root}
+}
+
+func serveSFTP(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+			}
+		}()
+		go func() {
+			defer channel.Close()
+			server, err := sftp.NewServer(channel)
+			if err != nil {
+				return
+			}
+			_ = server.Serve()
+		}()
+	}
+}
+
+func TestSFTPFetchFile(t *testing.T) {
+	fx := startSSHServer(t)
+	remote := filepath.Join(fx.root, "landscapes", "peak.jpg")
+	if err := os.WriteFile(remote, []byte("peak"), 0o644); err != nil {
+		t.Fatalf("write remote: %v", err)
+	}
+
+	proc := image.NewProcessor(nil, bytes.NewReader([]byte{1, 2, 3}))
+	proc.Register(&SFTP{Storer: proc, KnownHostsFile: fx.knownHosts, AgentSocket: fx.agentSock})
+
+	saveDir := t.TempDir()
+	got, err := proc.Process(context.Background(), "sftp://nas@"+fx.addr+filepath.ToSlash(remote), saveDir, false)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if filepath.Dir(got) != saveDir || filepath.Base(got) != "peak_010203.jpg" {
+		t.Fatalf("unexpected local path: %s", got)
+	}
+	if data, _ := os.ReadFile(got); string(data) != "peak" {
+		t.Fatalf("unexpected contents: %q", data)
+	}
+	if _, err := os.Stat(remote); err != nil {
+		t.Fatalf("expected remote file untouched: %v", err)
+	}
+
+	meta, ok, err := image.ReadMetadata(got)
+	if err != nil || !ok || meta.ImageURL != "sftp://nas@"+fx.addr+filepath.ToSlash(remote) {
+		t.Fatalf("unexpected metadata: %+v ok=%v err=%v", meta, ok, err)
+	}
+}
+
+func TestSFTPFetchDirectoryPicksImage(t *testing.T) {
+	fx := startSSHServer(t)
+	dir := filepath.Join(fx.root, "landscapes")
+	for name, data := range map[string]string{"a.png": "a", "b.jpg": "b", "notes.txt": "skip"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatalf("write remote: %v", err)
+		}
+	}
+
+	var choices int
+	s := &SFTP{
+		Storer:         image.NewProcessor(nil, nil),
+		KnownHostsFile: fx.knownHosts,
+		AgentSocket:    fx.agentSock,
+		Rand: func(n int) int {
+			choices = n
+			return 0
+		},
+	}
+
+	got, err := s.Fetch(context.Background(), "sftp://nas@"+fx.addr+filepath.ToSlash(dir), t.TempDir())
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if choices != 2 {
+		t.Fatalf("expected 2 image candidates, got %d", choices)
+	}
+	if data, _ := os.ReadFile(got); string(data) != "a" {
+		t.Fatalf("unexpected contents: %q", data)
+	}
+}
+
+func TestSFTPRejectsUnknownHost(t *testing.T) {
+	fx := startSSHServer(t)
+	emptyKnownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(emptyKnownHosts, nil, 0o600); err != nil {
+		t.Fatalf("write known_hosts: %v", err)
+	}
+
+	s := &SFTP{Storer: image.NewProcessor(nil, nil), KnownHostsFile: emptyKnownHosts, AgentSocket: fx.agentSock}
+	if _, err := s.Fetch(context.Background(), "sftp://nas@"+fx.addr+"/x.jpg", t.TempDir()); err == nil {
+		t.Fatal("expected host key verification failure")
+	}
+}
+
+func TestSFTPTimesOutOnSilentHost(t *testing.T) {
+	fx := startSSHServer(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		// Accept and never answer, like a tarpit.
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	s := &SFTP{
+		Storer:         image.NewProcessor(nil, nil),
+		KnownHostsFile: fx.knownHosts,
+		AgentSocket:    fx.agentSock,
+		Timeout:        100 * time.Millisecond,
+	}
+	start := time.Now()
+	if _, err := s.Fetch(context.Background(), "sftp://nas@"+ln.Addr().String()+"/x.jpg", t.TempDir()); err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("handshake took %v", elapsed)
+	}
+}
@@ -0,0 +1,94 @@
+// Package source contains remote image providers that plug into the
+// image.Processor source registry.
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+)
+
+const maxJSONSize = 10 << 20
+
+// Fetcher is the HTTP side of image.Processor. Sources query provider APIs
+// with Get and store the chosen image with Download.
+type Fetcher interface {
+	Get(ctx context.Context, rawURL string) (*http.Response, error)
+	Download(ctx context.Context, imageURL, dst string) (string, error)
+}
+
+func getJSON(ctx context.Context, f Fetcher, rawURL string, v any) error {
+	resp, err := f.Get(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(resp, v)
+}
+
+// getJSONWithKey is getJSON with an API key sent in header, so the key
+// never shows up in URLs, which error messages include. f must be a
+// Requester.
+func getJSONWithKey(ctx context.Context, f Fetcher, rawURL, header, key string, v any) error {
+	requester, ok := f.(Requester)
+	if !ok {
+		return errors.New("fetcher cannot send the API key")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(header, key)
+	resp, err := requester.Do(req)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(resp, v)
+}
+
+// decodeJSON reads a JSON API response into v and closes its body.
+func decodeJSON(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJSONSize)).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func hasPrefix(input, prefix string) bool {
+	return len(input) >= len(prefix) && strings.EqualFold(input[:len(prefix)], prefix)
+}
+
+// trimPrefix strips a case-insensitive scheme prefix and surrounding quotes.
+func trimPrefix(input, prefix string) string {
+	if hasPrefix(input, prefix) {
+		input = input[len(prefix):]
+	}
+	return strings.Trim(strings.TrimSpace(input), `"'`)
+}
+
+func pick(n int, randIntN func(int) int) int {
+	if randIntN == nil {
+		randIntN = rand.IntN
+	}
+	return randIntN(n)
+}
+
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
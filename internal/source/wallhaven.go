@@ -0,0 +1,145 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"wugo/internal/image"
+)
+
+const (
+	WallhavenPrefix      = "wallhaven:"
+	defaultWallhavenBase = "https://wallhaven.cc/api/v1"
+)
+
+// Wallhaven searches wallhaven.cc and stores a random result, e.g.
+// wallhaven:"nature 4k".
+type Wallhaven struct {
+	Fetcher Fetcher
+	// BaseURL is the API root, https://wallhaven.cc/api/v1 by default.
+	BaseURL string
+	APIKey  string
+	// Purity is a comma-separated list of sfw, sketchy and nsfw, or the raw
+	// three-digit API bitmask.
+	Purity  string
+	Ratio   string
+	AtLeast string
+	Rand    func(n int) int
+}
+
+type wallhavenSearch struct {
+	Data []wallhavenItem `json:"data"`
+}
+
+type wallhavenItem struct {
+	ID         string `json:"id"`
+	URL        string `json:"url"`
+	Path       string `json:"path"`
+	Source     string `json:"source"`
+	Category   string `json:"category"`
+	Purity     string `json:"purity"`
+	Resolution string `json:"resolution"`
+}
+
+func (w *Wallhaven) Match(input string) bool {
+	return hasPrefix(input, WallhavenPrefix)
+}
+
+func (w *Wallhaven) Fetch(ctx context.Context, input, dst string) (string, error) {
+	searchURL, err := w.searchURL(trimPrefix(input, WallhavenPrefix))
+	if err != nil {
+		return "", err
+	}
+
+	var result wallhavenSearch
+	if err := w.search(ctx, searchURL, &result); err != nil {
+		return "", fmt.Errorf("wallhaven search: %w", err)
+	}
+	if len(result.Data) == 0 {
+		return "", errors.New("wallhaven search returned no results")
+	}
+
+	item := result.Data[pick(len(result.Data), w.Rand)]
+	if item.Path == "" {
+		return "", fmt.Errorf("wallhaven result %s has no image path", item.ID)
+	}
+
+	localPath, err := w.Fetcher.Download(ctx, item.Path, dst)
+	if err != nil {
+		return "", err
+	}
+
+	meta := image.Metadata{
+		Source:      "wallhaven",
+		PageURL:     item.URL,
+		ImageURL:    item.Path,
+		Attribution: item.Source,
+		Tags:        nonEmpty(item.Category, item.Purity),
+	}
+	if err := image.WriteMetadata(localPath, meta); err != nil {
+		return "", fmt.Errorf("write metadata: %w", err)
+	}
+
+	return localPath, nil
+}
+
+func (w *Wallhaven) searchURL(query string) (string, error) {
+	base := w.BaseURL
+	if base == "" {
+		base = defaultWallhavenBase
+	}
+
+	params := url.Values{}
+	params.Set("sorting", "random")
+	if query != "" {
+		params.Set("q", query)
+	}
+	if w.Purity != "" {
+		purity, err := wallhavenPurity(w.Purity)
+		if err != nil {
+			return "", err
+		}
+		params.Set("purity", purity)
+	}
+	if w.Ratio != "" {
+		params.Set("ratios", w.Ratio)
+	}
+	if w.AtLeast != "" {
+		params.Set("atleast", w.AtLeast)
+	}
+
+	return strings.TrimRight(base, "/") + "/search?" + params.Encode(), nil
+}
+
+// search queries the API, sending the key if there is one.
+func (w *Wallhaven) search(ctx context.Context, searchURL string, v any) error {
+	if w.APIKey == "" {
+		return getJSON(ctx, w.Fetcher, searchURL, v)
+	}
+	return getJSONWithKey(ctx, w.Fetcher, searchURL, "X-API-Key", w.APIKey, v)
+}
+
+// wallhavenPurity converts "sfw,sketchy" style lists to the API bitmask.
+func wallhavenPurity(value string) (string, error) {
+	if len(value) == 3 && strings.Trim(value, "01") == "" {
+		return value, nil
+	}
+
+	bits := []byte("000")
+	for _, part := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "sfw":
+			bits[0] = '1'
+		case "sketchy":
+			bits[1] = '1'
+		case "nsfw":
+			bits[2] = '1'
+		default:
+			return "", fmt.Errorf("unknown wallhaven purity: %s", part)
+		}
+	}
+	return string(bits), nil
+}
@@ -0,0 +1,112 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"wugo/internal/image"
+)
+
+func TestWallhavenPurity(t *testing.T) {
+	cases := map[string]string{
+		"sfw":         "100",
+		"sfw,sketchy": "110",
+		"nsfw":        "001",
+		"111":         "111",
+	}
+	for in, want := range cases {
+		got, err := wallhavenPurity(in)
+		if err != nil {
+			t.Fatalf("%s: %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("%s: expected %s, got %s", in, want, got)
+		}
+	}
+	if _, err := wallhavenPurity("spicy"); err == nil {
+		t.Fatal("expected error for unknown purity")
+	}
+}
+
+func TestWallhavenFetch(t *testing.T) {
+	var query url.Values
+	var apiKey, rawQuery string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		apiKey, rawQuery = r.Header.Get("X-API-Key"), r.URL.RawQuery
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": []map[string]string{
+				{"id": "a1", "url": "https://wallhaven.cc/w/a1", "path": server.URL + "/full/a1.jpg", "category": "general", "purity": "sfw"},
+				{"id": "b2", "url": "https://wallhaven.cc/w/b2", "path": server.URL + "/full/b2.png", "category": "general", "purity": "sfw", "source": "https://artist.test/b2"},
+			},
+		})
+	})
+	mux.HandleFunc("/full/b2.png", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("b2"))
+	})
+
+	proc := image.NewProcessor(server.Client(), bytes.NewReader([]byte{1, 2, 3}))
+	wh := &Wallhaven{
+		Fetcher: proc,
+		BaseURL: server.URL + "/api/v1",
+		APIKey:  "secret",
+		Purity:  "sfw",
+		Ratio:   "16x9",
+		AtLeast: "2560x1440",
+		Rand:    func(int) int { return 1 },
+	}
+	proc.Register(wh)
+
+	got, err := proc.Process(context.Background(), `wallhaven:"nature 4k"`, t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+
+	if query.Get("q") != "nature 4k" || query.Get("purity") != "100" || query.Get("ratios") != "16x9" ||
+		query.Get("atleast") != "2560x1440" {
+		t.Fatalf("unexpected search query: %v", query)
+	}
+	if apiKey != "secret" || strings.Contains(rawQuery, "secret") {
+		t.Fatalf("expected the API key only in the header, got %q and query %q", apiKey, rawQuery)
+	}
+
+	data, err := os.ReadFile(got)
+	if err != nil {
+		t.Fatalf("read image: %v", err)
+	}
+	if string(data) != "b2" {
+		t.Fatalf("unexpected image: %s", data)
+	}
+
+	meta, ok, err := image.ReadMetadata(got)
+	if err != nil || !ok {
+		t.Fatalf("read metadata: ok=%v err=%v", ok, err)
+	}
+	if meta.Source != "wallhaven" || meta.PageURL != "https://wallhaven.cc/w/b2" || meta.Attribution != "https://artist.test/b2" {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+}
+
+func TestWallhavenNoResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data": []}`))
+	}))
+	defer server.Close()
+
+	wh := &Wallhaven{Fetcher: image.NewProcessor(server.Client(), nil), BaseURL: server.URL}
+	if _, err := wh.Fetch(context.Background(), "wallhaven:nothing", t.TempDir()); err == nil {
+		t.Fatal("expected error for empty search")
+	}
+}
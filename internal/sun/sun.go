@@ -0,0 +1,134 @@
+// Package sun computes the sun's position and sunrise and sunset times
+// offline with the NOAA solar calculator equations.
+package sun
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Horizon is the elevation, in degrees, at which the sun rises and sets:
+// the upper limb touching the horizon, allowing for refraction.
+const Horizon = -0.833
+
+// Event is a sunrise or sunset.
+type Event struct {
+	Time time.Time
+	Rise bool
+}
+
+// position holds the equation of time in minutes and the declination in
+// degrees for a moment.
+type position struct {
+	eqTime      float64
+	declination float64
+}
+
+func rad(d float64) float64 { return d * math.Pi / 180 }
+func deg(r float64) float64 { return r * 180 / math.Pi }
+
+func julianDay(t time.Time) float64 {
+	return float64(t.UTC().UnixNano())/float64(24*time.Hour) + 2440587.5
+}
+
+func solarPosition(t time.Time) position {
+	jc := (julianDay(t) - 2451545) / 36525
+
+	meanLong := math.Mod(280.46646+jc*(36000.76983+jc*0.0003032), 360)
+	meanAnom := 357.52911 + jc*(35999.05029-0.0001537*jc)
+	eccent := 0.016708634 - jc*(0.000042037+0.0000001267*jc)
+	center := math.Sin(rad(meanAnom))*(1.914602-jc*(0.004817+0.000014*jc)) +
+		math.Sin(rad(2*meanAnom))*(0.019993-0.000101*jc) +
+		math.Sin(rad(3*meanAnom))*0.000289
+	omega := 125.04 - 1934.136*jc
+	appLong := meanLong + center - 0.00569 - 0.00478*math.Sin(rad(omega))
+	meanObliq := 23 + (26+(21.448-jc*(46.815+jc*(0.00059-jc*0.001813)))/60)/60
+	obliq := meanObliq + 0.00256*math.Cos(rad(omega))
+
+	y := math.Pow(math.Tan(rad(obliq/2)), 2)
+	l, m := rad(meanLong), rad(meanAnom)
+	eqTime := 4 * deg(y*math.Sin(2*l)-2*eccent*math.Sin(m)+4*eccent*y*math.Sin(m)*math.Cos(2*l)-
+		0.5*y*y*math.Sin(4*l)-1.25*eccent*eccent*math.Sin(2*m))
+
+	return position{
+		eqTime:      eqTime,
+		declination: deg(math.Asin(math.Sin(rad(obliq)) * math.Sin(rad(appLong)))),
+	}
+}
+
+// Elevation returns the sun's geometric elevation above the horizon in
+// degrees at t, for a latitude and longitude in degrees (east positive).
+func Elevation(t time.Time, lat, lon float64) float64 {
+	p := solarPosition(t)
+	u := t.UTC()
+	minutes := float64(u.Hour()*60+u.Minute()) + float64(u.Second())/60
+	trueSolar := math.Mod(minutes+p.eqTime+4*lon, 1440)
+	if trueSolar < 0 {
+		trueSolar += 1440
+	}
+	hourAngle := trueSolar/4 - 180
+
+	cosZenith := math.Sin(rad(lat))*math.Sin(rad(p.declination)) +
+		math.Cos(rad(lat))*math.Cos(rad(p.declination))*math.Cos(rad(hourAngle))
+	return 90 - deg(math.Acos(math.Max(-1, math.Min(1, cosZenith))))
+}
+
+// RiseSet returns sunrise and sunset around solar noon of date's calendar
+// day, in date's location. ok is false during polar day or night.
+func RiseSet(date time.Time, lat, lon float64) (rise, set time.Time, ok bool) {
+	return AtElevation(date, lat, lon, Horizon)
+}
+
+// AtElevation returns when the sun passes elevation degrees in the morning
+// and in the evening of date's calendar day, in date's location. ok is false
+// when it stays above or below that elevation all day.
+func AtElevation(date time.Time, lat, lon, elevation float64) (morning, evening time.Time, ok bool) {
+	y, m, d := date.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	// Evaluate the sun at the approximate local solar noon.
+	noon := midnight.Add(time.Duration((720 - 4*lon) * float64(time.Minute)))
+	p := solarPosition(noon)
+
+	cosHA := math.Cos(rad(90-elevation))/(math.Cos(rad(lat))*math.Cos(rad(p.declination))) -
+		math.Tan(rad(lat))*math.Tan(rad(p.declination))
+	if cosHA < -1 || cosHA > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+	hourAngle := deg(math.Acos(cosHA))
+
+	solarNoon := 720 - 4*lon - p.eqTime
+	at := func(minutes float64) time.Time {
+		return midnight.Add(time.Duration(minutes * float64(time.Minute))).Round(time.Second).In(date.Location())
+	}
+	return at(solarNoon - 4*hourAngle), at(solarNoon + 4*hourAngle), true
+}
+
+// Events returns the sunrises and sunsets between from and to, in order.
+func Events(from, to time.Time, lat, lon float64) []Event {
+	var events []Event
+	for day := from.AddDate(0, 0, -1); !day.After(to.AddDate(0, 0, 1)); day = day.AddDate(0, 0, 1) {
+		rise, set, ok := RiseSet(day, lat, lon)
+		if !ok {
+			continue
+		}
+		for _, e := range []Event{{rise, true}, {set, false}} {
+			if !e.Time.Before(from) && !e.Time.After(to) {
+				events = append(events, e)
+			}
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events
+}
+
+// IsDay reports whether the sun is up at t: after the last sunrise and
+// before the next sunset, or above the horizon during polar day and night.
+func IsDay(t time.Time, lat, lon float64) bool {
+	events := Events(t.Add(-36*time.Hour), t, lat, lon)
+	if len(events) == 0 {
+		return Elevation(t, lat, lon) > Horizon
+	}
+	return events[len(events)-1].Rise
+}
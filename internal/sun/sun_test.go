@@ -0,0 +1,99 @@
+package sun
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRiseSet(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	newYork, _ := time.LoadLocation("America/New_York")
+	if berlin == nil || newYork == nil {
+		t.Skip("time zone data not available")
+	}
+
+	cases := []struct {
+		name      string
+		date      time.Time
+		lat, lon  float64
+		rise, set string
+	}{
+		{"berlin summer", time.Date(2026, 6, 21, 0, 0, 0, 0, berlin), 52.52, 13.405, "04:43", "21:33"},
+		{"new york winter", time.Date(2026, 1, 1, 0, 0, 0, 0, newYork), 40.7128, -74.006, "07:20", "16:39"},
+	}
+	for _, tc := range cases {
+		rise, set, ok := RiseSet(tc.date, tc.lat, tc.lon)
+		if !ok {
+			t.Fatalf("%s: expected sunrise and sunset", tc.name)
+		}
+		if !near(t, rise, tc.rise) || !near(t, set, tc.set) {
+			t.Fatalf("%s: got %s / %s, want %s / %s", tc.name, rise.Format("15:04"), set.Format("15:04"), tc.rise, tc.set)
+		}
+	}
+}
+
+func near(t *testing.T, got time.Time, want string) bool {
+	t.Helper()
+	w, err := time.ParseInLocation("15:04", want, got.Location())
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	minutes := got.Hour()*60 + got.Minute() - (w.Hour()*60 + w.Minute())
+	return math.Abs(float64(minutes)) <= 2
+}
+
+func TestPolarDayAndNight(t *testing.T) {
+	tromso := [2]float64{69.65, 18.96}
+	summer := time.Date(2026, 6, 21, 0, 0, 0, 0, time.UTC)
+	winter := time.Date(2026, 12, 21, 12, 0, 0, 0, time.UTC)
+
+	if _, _, ok := RiseSet(summer, tromso[0], tromso[1]); ok {
+		t.Fatal("expected midnight sun")
+	}
+	if !IsDay(summer, tromso[0], tromso[1]) {
+		t.Fatal("expected day at midnight during polar day")
+	}
+	if IsDay(winter, tromso[0], tromso[1]) {
+		t.Fatal("expected night at noon during polar night")
+	}
+}
+
+func TestEventsAndElevation(t *testing.T) {
+	lat, lon := 48.8566, 2.3522
+	from := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	events := Events(from, from.Add(48*time.Hour), lat, lon)
+	if len(events) != 4 || !events[0].Rise || events[1].Rise || !events[2].Rise || events[3].Rise {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	for _, e := range events {
+		if el := Elevation(e.Time, lat, lon); math.Abs(el-Horizon) > 0.3 {
+			t.Fatalf("expected elevation near the horizon at %s, got %.2f", e.Time, el)
+		}
+	}
+	noon := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+	if el := Elevation(noon, lat, lon); el < 40 || el > 42 {
+		t.Fatalf("unexpected equinox noon elevation in Paris: %.2f", el)
+	}
+	if !IsDay(noon, lat, lon) || IsDay(noon.Add(12*time.Hour), lat, lon) {
+		t.Fatal("expected day at noon and night at midnight")
+	}
+}
+
+func TestAtElevation(t *testing.T) {
+	lat, lon := 48.8566, 2.3522
+	date := time.Date(2026, 6, 21, 0, 0, 0, 0, time.UTC)
+	morning, evening, ok := AtElevation(date, lat, lon, 30)
+	if !ok || !morning.Before(evening) {
+		t.Fatalf("unexpected times: %s %s %t", morning, evening, ok)
+	}
+	for _, at := range []time.Time{morning, evening} {
+		if el := Elevation(at, lat, lon); math.Abs(el-30) > 0.3 {
+			t.Fatalf("expected elevation 30 at %s, got %.2f", at, el)
+		}
+	}
+	if _, _, ok := AtElevation(date, lat, lon, 70); ok {
+		t.Fatal("expected the sun never to reach 70 degrees in Paris")
+	}
+}
@@ -20,8 +20,27 @@ func (DBusRunner) Run(script string) error {
 	return obj.Call("org.kde.PlasmaShell.evaluateScript", 0, script).Err
 }
 
+func (DBusRunner) Evaluate(script string) (string, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	var output string
+	obj := conn.Object("org.kde.plasmashell", "/PlasmaShell")
+	err = obj.Call("org.kde.PlasmaShell.evaluateScript", 0, script).Store(&output)
+	return output, err
+}
+
 type OSFileWriter struct{}
 
 func (OSFileWriter) WriteFile(name string, data []byte, perm fs.FileMode) error {
 	return os.WriteFile(name, data, perm)
 }
+
+type OSFileReader struct{}
+
+func (OSFileReader) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
@@ -1,17 +1,26 @@
 package wallpaper
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 )
 
+// lockscreenGroup is the kscreenlockerrc group holding the lock screen image.
+const lockscreenGroup = "[Greeter][Wallpaper][org.kde.image][General]"
+
 type KDESetter struct {
 	Runner  ScriptRunner
 	Writer  FileWriter
+	Reader  FileReader
 	HomeDir func() (string, error)
 }
 
@@ -19,10 +28,16 @@ func NewKDESetter() *KDESetter {
 	return &KDESetter{
 		Runner:  DBusRunner{},
 		Writer:  OSFileWriter{},
+		Reader:  OSFileReader{},
 		HomeDir: os.UserHomeDir,
 	}
 }
 
+// Name identifies the backend to hooks.
+func (k *KDESetter) Name() string {
+	return "kde"
+}
+
 func (k *KDESetter) SetDesktop(_ context.Context, imagePath string) error {
 	k.applyDefaults()
 
@@ -48,14 +63,101 @@ func (k *KDESetter) SetLockscreen(_ context.Context, imagePath string) error {
 
 	uri := fileURI(imagePath)
 	path := filepath.Join(home, ".config", "kscreenlockerrc")
-	content := fmt.Sprintf(`[Greeter][Wallpaper][org.kde.image][General]
+	content := fmt.Sprintf(`%s
 Image=%s
 PreviewImage=%s
-`, uri, uri)
+`, lockscreenGroup, uri, uri)
 
 	return k.Writer.WriteFile(path, []byte(content), 0o644)
 }
 
+// GetDesktop returns the plugin and image of every desktop, keyed by
+// containment id.
+func (k *KDESetter) GetDesktop(_ context.Context) ([]Desktop, error) {
+	k.applyDefaults()
+
+	eval, ok := k.Runner.(ScriptEvaluator)
+	if !ok {
+		return nil, errors.New("script runner cannot read the desktop wallpaper")
+	}
+	output, err := eval.Evaluate(`var d = desktops(); var out = [];
+for (var i = 0; i < d.length; i++) {
+	d[i].currentConfigGroup = ["Wallpaper", "org.kde.image", "General"];
+	out.push({id: String(d[i].id), plugin: d[i].wallpaperPlugin, image: d[i].readConfig("Image")});
+}
+print(JSON.stringify(out));`)
+	if err != nil {
+		return nil, err
+	}
+
+	var desktops []Desktop
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &desktops); err != nil {
+		return nil, fmt.Errorf("read desktops: %w", err)
+	}
+	for i := range desktops {
+		desktops[i].Image = filePath(desktops[i].Image)
+	}
+	return desktops, nil
+}
+
+// RestoreDesktop puts back the plugins and images GetDesktop returned.
+// Desktops that did not exist then are left alone.
+func (k *KDESetter) RestoreDesktop(_ context.Context, desktops []Desktop) error {
+	k.applyDefaults()
+
+	saved := make(map[string]Desktop, len(desktops))
+	for _, d := range desktops {
+		if d.Image != "" {
+			d.Image = fileURI(d.Image)
+		}
+		saved[d.ID] = d
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	script := fmt.Sprintf(`var saved = %s; var d = desktops();
+for (var i = 0; i < d.length; i++) {
+	var s = saved[String(d[i].id)];
+	if (!s) continue;
+	if (s.plugin) d[i].wallpaperPlugin = s.plugin;
+	d[i].currentConfigGroup = ["Wallpaper", "org.kde.image", "General"];
+	d[i].writeConfig("Image", s.image || "");
+}`, data)
+
+	return k.Runner.Run(script)
+}
+
+// GetLockscreen returns the image kscreenlockerrc configures.
+func (k *KDESetter) GetLockscreen(_ context.Context) (string, error) {
+	k.applyDefaults()
+
+	home, err := k.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	data, err := k.Reader.ReadFile(filepath.Join(home, ".config", "kscreenlockerrc"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	var group string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			group = line
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "Image="); ok && group == lockscreenGroup {
+			return filePath(value), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
 func (k *KDESetter) applyDefaults() {
 	if k.Runner == nil {
 		k.Runner = DBusRunner{}
@@ -63,6 +165,9 @@ func (k *KDESetter) applyDefaults() {
 	if k.Writer == nil {
 		k.Writer = OSFileWriter{}
 	}
+	if k.Reader == nil {
+		k.Reader = OSFileReader{}
+	}
 	if k.HomeDir == nil {
 		k.HomeDir = os.UserHomeDir
 	}
@@ -72,3 +177,12 @@ func fileURI(path string) string {
 	uri := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
 	return uri.String()
 }
+
+// filePath undoes fileURI. Values that are not file URIs are returned as is.
+func filePath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
@@ -3,6 +3,7 @@ package wallpaper
 import (
 	"context"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -10,6 +11,7 @@ import (
 
 type fakeRunner struct {
 	script string
+	output string
 	err    error
 }
 
@@ -18,6 +20,11 @@ func (f *fakeRunner) Run(script string) error {
 	return f.err
 }
 
+func (f *fakeRunner) Evaluate(script string) (string, error) {
+	f.script = script
+	return f.output, f.err
+}
+
 type fakeWriter struct {
 	path string
 	data []byte
@@ -82,3 +89,62 @@ func TestSetLockscreenWritesConfig(t *testing.T) {
 		t.Fatalf("missing preview entry: %s", content)
 	}
 }
+
+func TestGetDesktopReadsEveryDesktop(t *testing.T) {
+	runner := &fakeRunner{output: `[{"id":"1","plugin":"org.kde.image","image":"file:///home/user/My%20Picture.png"},` +
+		`{"id":"7","plugin":"org.kde.color","image":""}]` + "\n"}
+	setter := &KDESetter{Runner: runner}
+
+	got, err := setter.GetDesktop(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Desktop{{ID: "1", Plugin: "org.kde.image", Image: "/home/user/My Picture.png"}, {ID: "7", Plugin: "org.kde.color"}}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("unexpected desktops: %+v", got)
+	}
+	if !strings.Contains(runner.script, "readConfig(\"Image\")") {
+		t.Fatalf("script does not read the image: %s", runner.script)
+	}
+}
+
+func TestRestoreDesktopScript(t *testing.T) {
+	runner := &fakeRunner{}
+	setter := &KDESetter{Runner: runner}
+
+	err := setter.RestoreDesktop(context.Background(), []Desktop{
+		{ID: "1", Plugin: "org.kde.image", Image: "/home/user/left.png"},
+		{ID: "7", Plugin: "org.kde.color"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		`"1":{"id":"1","plugin":"org.kde.image","image":"file:///home/user/left.png"}`,
+		`"7":{"id":"7","plugin":"org.kde.color"}`,
+	} {
+		if !strings.Contains(runner.script, want) {
+			t.Fatalf("script missing %s: %s", want, runner.script)
+		}
+	}
+}
+
+func TestGetLockscreenReadsSetValue(t *testing.T) {
+	home := t.TempDir()
+	if err := os.Mkdir(filepath.Join(home, ".config"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	setter := &KDESetter{HomeDir: func() (string, error) { return home, nil }}
+
+	got, err := setter.GetLockscreen(context.Background())
+	if err != nil || got != "" {
+		t.Fatalf("expected no image without a config, got %q (%v)", got, err)
+	}
+	if err := setter.SetLockscreen(context.Background(), "/home/user/My Picture.png"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	got, err = setter.GetLockscreen(context.Background())
+	if err != nil || got != "/home/user/My Picture.png" {
+		t.Fatalf("unexpected lock screen: %q (%v)", got, err)
+	}
+}
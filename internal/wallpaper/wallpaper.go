@@ -10,10 +10,35 @@ type Setter interface {
 	SetLockscreen(ctx context.Context, imagePath string) error
 }
 
+// Getter reads back what a Setter changes, so a failed change can be
+// undone. An empty lock screen path means there is no image to restore.
+type Getter interface {
+	GetDesktop(ctx context.Context) ([]Desktop, error)
+	RestoreDesktop(ctx context.Context, desktops []Desktop) error
+	GetLockscreen(ctx context.Context) (string, error)
+}
+
+// Desktop is the wallpaper of one desktop or screen.
+type Desktop struct {
+	ID     string `json:"id"`
+	Plugin string `json:"plugin,omitempty"`
+	Image  string `json:"image,omitempty"`
+}
+
 type ScriptRunner interface {
 	Run(script string) error
 }
 
+// ScriptEvaluator is a ScriptRunner that also returns what the script
+// printed.
+type ScriptEvaluator interface {
+	Evaluate(script string) (string, error)
+}
+
 type FileWriter interface {
 	WriteFile(name string, data []byte, perm fs.FileMode) error
 }
+
+type FileReader interface {
+	ReadFile(name string) ([]byte, error)
+}